@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeValidationFailed  = "validation_failed"
+	CodeUnauthorized      = "unauthorized"
+	CodeNotFound          = "not_found"
+	CodeExpired           = "expired"
+	CodeInvalidPassphrase = "invalid_passphrase"
+	CodeStoreFull         = "store_full"
+	CodeInternalError     = "internal_error"
+)
+
+const maxBodyBytes = 64 * 1024
+
+type ErrorBody struct {
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type Responder struct {
+	logger *slog.Logger
+}
+
+func NewResponder(logger *slog.Logger) *Responder {
+	return &Responder{logger: logger}
+}
+
+func (r *Responder) Decode(w http.ResponseWriter, req *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("decode request body: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Responder) JSON(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	writeJSONHeader(w, status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		r.logger.LogAttrs(ctx, slog.LevelError, "Failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (r *Responder) SensitiveJSON(ctx context.Context, w http.ResponseWriter, status int, field string, value []byte) {
+	const maxEscapedRuneLen = 6
+
+	buf := make([]byte, 0, len(field)+maxEscapedRuneLen*len(value)+16)
+	buf = append(buf, '{')
+	buf = appendJSONString(buf, []byte(field))
+	buf = append(buf, ':')
+	buf = appendJSONString(buf, value)
+	buf = append(buf, '}', '\n')
+	defer clear(buf)
+
+	writeJSONHeader(w, status)
+
+	if _, err := w.Write(buf); err != nil {
+		r.logger.LogAttrs(ctx, slog.LevelError, "Failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func writeJSONHeader(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+}
+
+func appendJSONString(dst, src []byte) []byte {
+	const hex = "0123456789abcdef"
+
+	dst = append(dst, '"')
+	for i := 0; i < len(src); {
+		c := src[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				dst = append(dst, '\\', c)
+			case c == '\n':
+				dst = append(dst, '\\', 'n')
+			case c == '\r':
+				dst = append(dst, '\\', 'r')
+			case c == '\t':
+				dst = append(dst, '\\', 't')
+			case c < ' ' || c == '<' || c == '>' || c == '&':
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				dst = append(dst, c)
+			}
+			i++
+
+			continue
+		}
+
+		r, size := utf8.DecodeRune(src[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			dst = append(dst, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xf])
+		default:
+			dst = append(dst, src[i:i+size]...)
+		}
+		i += size
+	}
+
+	return append(dst, '"')
+}
+
+func (r *Responder) Error(ctx context.Context, w http.ResponseWriter, status int, body ErrorBody) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	r.logger.LogAttrs(ctx, level, "API error", slog.String("code", body.Code), slog.String("error", body.Message))
+
+	r.JSON(ctx, w, status, ErrorResponse{Error: body})
+}
+
+func (r *Responder) ServerError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.logger.LogAttrs(ctx, slog.LevelError, "Server error", slog.String("error", err.Error()))
+
+	r.JSON(ctx, w, http.StatusInternalServerError, ErrorResponse{Error: ErrorBody{
+		Code:    CodeInternalError,
+		Message: "Internal server error",
+	}})
+}
+
+var errInvalidToken = errors.New("missing or invalid bearer token")
+
+func NewBearerAuthMiddleware(responder *Responder, tokens []string) func(http.Handler) http.Handler {
+	hashes := make([][sha256.Size]byte, 0, len(tokens))
+	for _, token := range tokens {
+		hashes = append(hashes, sha256.Sum256([]byte(token)))
+	}
+
+	authorized := func(r *http.Request) bool {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return false
+		}
+
+		hash := sha256.Sum256([]byte(token))
+		match := 0
+		for _, expected := range hashes {
+			match |= subtle.ConstantTimeCompare(hash[:], expected[:])
+		}
+
+		return match == 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !authorized(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sharesecrets"`)
+				responder.Error(r.Context(), w, http.StatusUnauthorized, ErrorBody{
+					Code:    CodeUnauthorized,
+					Message: errInvalidToken.Error(),
+				})
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it writes json", func(t *testing.T) {
+		logger, output := loggertest.New()
+		responder := NewResponder(logger)
+		w := httptest.NewRecorder()
+
+		responder.JSON(ctx, w, http.StatusCreated, map[string]string{"key": "value"})
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		require.JSONEq(t, `{"key": "value"}`, w.Body.String())
+		require.Empty(t, output(t))
+	})
+
+	t.Run("it writes sensitive json like encoding/json", func(t *testing.T) {
+		values := map[string]string{
+			"plain":        "Message",
+			"escapes":      "quote \" backslash \\ newline \n tab \t return \r bell \a",
+			"html":         "<script>&</script>",
+			"unicode":      "привет, 世界 🎉",
+			"separators":   "line\u2028paragraph\u2029",
+			"invalid utf8": "bad \xff byte \xe2\x82",
+			"empty":        "",
+		}
+
+		for name, value := range values {
+			t.Run(name, func(t *testing.T) {
+				logger, output := loggertest.New()
+				responder := NewResponder(logger)
+				w := httptest.NewRecorder()
+				expected := httptest.NewRecorder()
+
+				message := []byte(value)
+				responder.SensitiveJSON(ctx, w, http.StatusOK, "message", message)
+				responder.JSON(ctx, expected, http.StatusOK, map[string]string{"message": value})
+
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, expected.Header(), w.Header())
+				require.Equal(t, expected.Body.String(), w.Body.String())
+				require.Equal(t, []byte(value), message)
+				require.Empty(t, output(t))
+			})
+		}
+	})
+
+	t.Run("it writes errors", func(t *testing.T) {
+		logger, output := loggertest.New()
+		responder := NewResponder(logger)
+		w := httptest.NewRecorder()
+
+		responder.Error(ctx, w, http.StatusNotFound, ErrorBody{Code: CodeNotFound, Message: "Secret not found"})
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.JSONEq(t, `{"error": {"code": "not_found", "message": "Secret not found"}}`, w.Body.String())
+		require.Equal(
+			t,
+			[]map[string]any{{"level": "INFO", "msg": "API error", "code": "not_found", "error": "Secret not found"}},
+			output(t),
+		)
+	})
+
+	t.Run("it hides server errors", func(t *testing.T) {
+		logger, output := loggertest.New()
+		responder := NewResponder(logger)
+		w := httptest.NewRecorder()
+
+		responder.ServerError(ctx, w, io.EOF)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.JSONEq(t, `{"error": {"code": "internal_error", "message": "Internal server error"}}`, w.Body.String())
+		require.Equal(t, []map[string]any{{"level": "ERROR", "msg": "Server error", "error": io.EOF.Error()}}, output(t))
+	})
+
+	t.Run("it decodes strict json", func(t *testing.T) {
+		logger, _ := loggertest.New()
+		responder := NewResponder(logger)
+
+		var v struct{ Key string }
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"key": "value"}`))
+		err := responder.Decode(httptest.NewRecorder(), r, &v)
+		require.NoError(t, err)
+		require.Equal(t, "value", v.Key)
+
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"unknown": "value"}`))
+		err = responder.Decode(httptest.NewRecorder(), r, &v)
+		require.Error(t, err)
+	})
+}
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		status int
+	}{
+		"valid token":          {header: "Bearer first", status: http.StatusOK},
+		"another valid token":  {header: "Bearer second", status: http.StatusOK},
+		"invalid token":        {header: "Bearer third", status: http.StatusUnauthorized},
+		"missing token":        {header: "", status: http.StatusUnauthorized},
+		"empty token":          {header: "Bearer ", status: http.StatusUnauthorized},
+		"unsupported scheme":   {header: "Basic Zmlyc3Q6", status: http.StatusUnauthorized},
+		"token without scheme": {header: "first", status: http.StatusUnauthorized},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := loggertest.New()
+			middleware := NewBearerAuthMiddleware(NewResponder(logger), []string{"first", "second"})
+			handler := middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if test.header != "" {
+				r.Header.Set("Authorization", test.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code)
+			if test.status == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="sharesecrets"`, w.Header().Get("WWW-Authenticate"))
+				require.Contains(t, w.Body.String(), CodeUnauthorized)
+			}
+		})
+	}
+}
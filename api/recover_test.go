@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	t.Run("it recovers panic with json error and logs it", func(t *testing.T) {
+		logger, logs := loggertest.New()
+
+		handler := NewRecoverMiddleware(logger, NewResponder(logger))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("some panic")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/secrets", nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"error":{"code":"internal_error","message":"Internal server error"}}`, w.Body.String())
+
+		// here we have two log entries: one from middleware and one from Responder.ServerError
+		out := logs(t)
+		require.Len(t, out, 2)
+		require.Equal(t, "ERROR", out[0]["level"])
+		require.Equal(t, "Panic recovered", out[0]["msg"])
+		require.Equal(t, "some panic", out[0]["error"])
+		require.NotEmpty(t, out[0]["stack"])
+	})
+}
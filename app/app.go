@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
+	"maps"
+	"regexp"
 	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pugkong/sharesecrets/health"
 	"github.com/pugkong/sharesecrets/logger"
+	"github.com/pugkong/sharesecrets/proxy"
 	"github.com/pugkong/sharesecrets/secret"
+	"github.com/pugkong/sharesecrets/tracing"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type App struct {
@@ -25,37 +29,83 @@ func New(getenv func(string) string) *App {
 }
 
 func (a *App) Run(ctx context.Context) error {
-	logger := logger.New(a.env.LogOutput(), a.env.LogLevel(), a.env.TintedLogger())
+	logger := a.newLogger()
 
 	slog.SetLogLoggerLevel(slog.LevelError)
 	slog.SetDefault(logger.With(slog.String("layer", "fallback")))
 
-	var pool *pgxpool.Pool
-	if strings.HasPrefix(a.env.DB(), "postgres") {
-		var err error
-		pool, err = pgxpool.New(ctx, a.env.DB())
+	if endpoint := a.env.OTLPEndpoint(); endpoint != "" {
+		provider, err := tracing.NewProvider(ctx, endpoint)
 		if err != nil {
-			logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize postgres pool",
-				slog.String("error", err.Error()),
-			)
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize tracing", slog.String("error", err.Error()))
 
-			return fmt.Errorf("postgres pool initialization: %w", err)
+			return fmt.Errorf("tracing initialization: %w", err)
 		}
-		defer pool.Close()
+		defer func() {
+			if err := provider.Shutdown(context.WithoutCancel(ctx)); err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "Failed to shutdown tracing", slog.String("error", err.Error()))
+			}
+		}()
 
-		logger.InfoContext(ctx, "Using postgres storage")
+		tracing.SetGlobal(provider)
+		logger.InfoContext(ctx, "Tracing enabled")
 	} else {
-		logger.InfoContext(ctx, "Using in-memory storage")
+		tracing.SetGlobal(noop.NewTracerProvider())
+	}
+
+	storage, err := a.openStorage(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if storage.Snapshotter != nil {
+		if err := storage.Snapshotter.Restore(ctx); err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to restore snapshot", slog.String("error", err.Error()))
+
+			return fmt.Errorf("snapshot restore: %w", err)
+		}
 	}
 
-	secrets, err := a.makeSecretsService(ctx, logger, pool)
+	secrets, err := a.makeSecretsService(ctx, logger, storage.Store)
 	if err != nil {
 		logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize secrets service", slog.String("error", err.Error()))
 
 		return err
 	}
 
-	server := newServer(logger.With("layer", "http"), secrets, a.env.ListenAddr())
+	checks := map[string]health.Check{"cleanup": secrets.CheckCleanup}
+	maps.Copy(checks, storage.Checks)
+	health := health.NewHandler(logger.With(slog.String("layer", "health")), checks)
+
+	listener, err := listen(listenConfig{
+		Address:    a.env.ListenAddr(),
+		Mode:       a.env.ListenMode(),
+		SystemdFDs: a.env.SystemdListenFDs(),
+	})
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to open HTTP listener", slog.String("error", err.Error()))
+
+		return fmt.Errorf("http listen: %w", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	server := newServer(logger.With("layer", "http"), secrets, health, listener, serverConfig{
+		Listen:         a.env.ListenAddr(),
+		DrainPeriod:    a.env.DrainPeriod(),
+		APITokens:      a.env.APITokens(),
+		TLSCert:        a.env.TLSCert(),
+		TLSKey:         a.env.TLSKey(),
+		RedirectListen: a.env.TLSRedirectListen(),
+		Proxy: proxy.Config{
+			PublicURL:      a.env.PublicURL(),
+			TrustedProxies: a.env.TrustedProxies(),
+		},
+		HSTS:      a.env.HSTS(),
+		CSRFKey:   a.env.CSRFKey(),
+		AssetsDir: a.env.AssetsDir(),
+		Branding:  a.env.Branding(),
+	})
 	if err := server.Init(ctx); err != nil {
 		return err
 	}
@@ -76,34 +126,51 @@ func (a *App) Run(ctx context.Context) error {
 
 	start(server.Run)
 	start(secrets.CleanupLoop)
+	start(cleanupOnSignal(logger, secrets))
+	if storage.Snapshotter != nil {
+		start(storage.Snapshotter.Run)
+	}
 
 	logger.Info("Application started")
 	services.Wait()
+
+	if storage.Snapshotter != nil {
+		if err := storage.Snapshotter.Save(context.WithoutCancel(ctx)); err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to save snapshot", slog.String("error", err.Error()))
+		}
+	}
 	logger.Info("Application stopped")
 
 	return fmt.Errorf("app run: %w", context.Cause(ctx))
 }
 
-func (a *App) makeSecretsService(ctx context.Context, logger *slog.Logger, pool *pgxpool.Pool) (*secret.Service, error) {
-	encryptor := secret.NewSecretboxEncryptor(logger.With(slog.String("layer", "encryptor")))
+var secretKeyPaths = []*regexp.Regexp{
+	regexp.MustCompile(`^/([0-9a-f]{32})$`),
+	regexp.MustCompile(`^/api/v1/secrets/([0-9a-f]{32})/open$`),
+}
 
-	var store secret.Store
-	if pool != nil {
-		s := secret.NewPgStore(pool)
-		if err := s.Init(ctx); err != nil {
-			return nil, fmt.Errorf("secret pg store initialization: %w", err)
-		}
+func (a *App) newLogger() *slog.Logger {
+	redaction := logger.Redaction{
+		Attrs:   append([]string{"key"}, a.env.LogRedactAttrs()...),
+		Paths:   secretKeyPaths,
+		Replace: secret.KeyFingerprint,
+	}
 
-		store = s
-	} else {
-		store = secret.NewInMemoryStore(logger.With(slog.String("layer", "store")))
+	return logger.New(a.env.LogOutput(), a.env.LogLevel(), a.env.TintedLogger(), redaction)
+}
+
+func (a *App) makeSecretsService(ctx context.Context, logger *slog.Logger, store secret.Store) (*secret.Service, error) {
+	encryptor := secret.NewSecretboxEncryptor(logger.With(slog.String("layer", "encryptor")))
+
+	if err := migrate(ctx, store); err != nil {
+		return nil, err
 	}
 
 	return secret.NewService(
 		logger.With(slog.String("layer", "service")),
 		encryptor,
 		store,
-		time.Minute,
+		a.env.CleanupSchedule(),
 		time.Now,
 	), nil
 }
@@ -3,7 +3,9 @@ package app
 import (
 	"context"
 	"net"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -36,6 +38,43 @@ func TestApp(t *testing.T) {
 	})
 }
 
+func TestApp_Health(t *testing.T) {
+	addr, free := occupyRandomPort(t)
+	free()
+
+	env := mapenv(map[string]string{
+		"APP_LISTEN":       addr,
+		"APP_LOG_OUTPUT":   "discard",
+		"APP_DRAIN_PERIOD": "500ms",
+	})
+	app := New(env)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- app.Run(ctx) }()
+
+	status := func(path string) int {
+		response, err := http.Get("http://" + addr + path) //nolint:noctx
+		if err != nil {
+			return 0
+		}
+		defer response.Body.Close()
+
+		return response.StatusCode
+	}
+
+	require.Eventually(t, func() bool { return status("/healthz") == http.StatusOK }, time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusOK, status("/readyz"))
+
+	cancel()
+	require.Eventually(t, func() bool { return status("/readyz") == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusOK, status("/healthz"))
+
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
 func occupyRandomPort(t *testing.T) (string, func()) {
 	t.Helper()
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
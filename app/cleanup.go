@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/pugkong/sharesecrets/secret"
+)
+
+func cleanupOnSignal(logger *slog.Logger, secrets *secret.Service) func(context.Context) error {
+	signals := make(chan os.Signal, 1)
+	notifyCleanupSignal(signals)
+
+	return func(ctx context.Context) error {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case sig := <-signals:
+				if secrets.TriggerCleanup() {
+					logger.LogAttrs(ctx, slog.LevelInfo, "Secrets cleanup requested", slog.String("signal", sig.String()))
+				} else {
+					logger.LogAttrs(ctx, slog.LevelInfo, "Secrets cleanup already requested", slog.String("signal", sig.String()))
+				}
+			case <-ctx.Done():
+				return fmt.Errorf("cleanup signals: %w", ctx.Err())
+			}
+		}
+	}
+}
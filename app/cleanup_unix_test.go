@@ -0,0 +1,57 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupOnSignal(t *testing.T) {
+	logger, output := loggertest.New()
+	store := secret.NewInMemoryStore(logger, secret.InMemoryLimits{})
+	secrets := secret.NewService(
+		logger,
+		secret.NewSecretboxEncryptor(logger),
+		store,
+		secret.CleanupSchedule{Interval: time.Hour},
+		time.Now,
+	)
+
+	logged := func(msg, reason string) func() bool {
+		return func() bool {
+			for _, log := range output(t) {
+				if log["msg"] == msg && log["reason"] == reason {
+					return true
+				}
+			}
+
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 2)
+	go func() { done <- cleanupOnSignal(logger, secrets)(ctx) }()
+	go func() { done <- secrets.CleanupLoop(ctx) }()
+
+	require.Eventually(t, logged("Secrets cleanup completed", "startup"), time.Second, 10*time.Millisecond)
+
+	expired := secret.NewSecret([]byte("data"), 1, time.Now().Add(-time.Minute))
+	require.NoError(t, store.Save(ctx, "expired", expired))
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	require.Eventually(t, logged("Secrets cleanup started", "trigger"), time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return store.Usage().Secrets == 0 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.ErrorIs(t, <-done, context.Canceled)
+}
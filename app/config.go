@@ -0,0 +1,478 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pugkong/sharesecrets/proxy"
+	"github.com/pugkong/sharesecrets/secret"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configPathEnv = "APP_CONFIG"
+	fileEnvSuffix = "_FILE"
+	redacted      = "********"
+)
+
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+type setting struct {
+	name      string
+	fallback  string
+	sensitive bool
+	usage     string
+	validate  func(string) error
+}
+
+func settings() []setting {
+	return []setting{
+		{name: "APP_LISTEN", fallback: "127.0.0.1:8000", usage: "listen address, host:port or unix:///path", validate: validateListen},
+		{name: "APP_LISTEN_MODE", fallback: "0660", usage: "unix socket file mode", validate: validateFileMode},
+		{name: "APP_LOGGER", fallback: "json", usage: "log format", validate: validateOneOf("json", "tinted")},
+		{name: "APP_LOG_LEVEL", fallback: "info", usage: "log level", validate: validateOneOf("debug", "info", "warn", "error")},
+		{name: "APP_LOG_OUTPUT", fallback: "stderr", usage: "log output", validate: validateOneOf("stderr", "stdout", "discard")},
+		{name: "APP_LOG_REDACT_ATTRS", usage: "comma-separated extra log attributes to redact"},
+		{name: "APP_DB", sensitive: true, usage: "storage URL, in-memory when empty", validate: validateDB},
+		{name: "APP_OTLP_ENDPOINT", usage: "OTLP traces endpoint"},
+		{name: "APP_DRAIN_PERIOD", fallback: "0s", usage: "readiness drain period before shutdown", validate: validateDuration(0)},
+		{name: "APP_API_TOKENS", sensitive: true, usage: "comma-separated API bearer tokens"},
+		{name: "APP_PG_CLEANUP_BATCH_SIZE", fallback: "1000", usage: "postgres cleanup batch size", validate: validateInt(1)},
+		{name: "APP_PG_CLEANUP_PAUSE", fallback: "100ms", usage: "postgres cleanup pause between batches", validate: validateDuration(0)},
+		{name: "APP_PG_CLEANUP_CUTOFF", fallback: "30s", usage: "postgres cleanup run limit", validate: validateDuration(1)},
+		{name: "APP_MEMORY_MAX_SECRETS", fallback: "100000", usage: "in-memory secrets limit, 0 is unlimited", validate: validateInt(0)},
+		{name: "APP_MEMORY_MAX_BYTES", fallback: "268435456", usage: "in-memory bytes limit, 0 is unlimited", validate: validateInt(0)},
+		{name: "APP_SNAPSHOT_PATH", usage: "in-memory snapshot file"},
+		{name: "APP_SNAPSHOT_KEY", sensitive: true, usage: "in-memory snapshot key", validate: validateSnapshotKey},
+		{name: "APP_SNAPSHOT_INTERVAL", fallback: "1m", usage: "in-memory snapshot interval", validate: validateDuration(1)},
+		{name: "APP_CLEANUP_INTERVAL", fallback: "1m", usage: "expired secrets cleanup interval", validate: validateDuration(1)},
+		{name: "APP_CLEANUP_JITTER", fallback: "10s", usage: "expired secrets cleanup jitter", validate: validateDuration(0)},
+		{name: "APP_TLS_CERT", usage: "TLS certificate file"},
+		{name: "APP_TLS_KEY", usage: "TLS key file"},
+		{name: "APP_TLS_REDIRECT_LISTEN", usage: "HTTP to HTTPS redirect listen address", validate: validateHostPort},
+		{name: "APP_PUBLIC_URL", usage: "public base URL for links", validate: validatePublicURL},
+		{name: "APP_TRUSTED_PROXIES", usage: "comma-separated trusted proxy IPs or CIDRs", validate: validatePrefixes},
+		{name: "APP_HSTS_MAX_AGE", fallback: "0s", usage: "Strict-Transport-Security max age", validate: validateDuration(0)},
+		{name: "APP_HSTS_INCLUDE_SUBDOMAINS", fallback: "false", usage: "extend HSTS to subdomains", validate: validateBool},
+		{name: "APP_CSRF_KEY", sensitive: true, usage: "CSRF signing key", validate: validateCSRFKey},
+		{name: "APP_ASSETS_DIR", usage: "assets override directory"},
+		{name: "APP_BRAND_NAME", fallback: "ShareSecrets", usage: "product name"},
+		{name: "APP_BRAND_LOGO", usage: "logo file in assets directory"},
+		{name: "APP_BRAND_FOOTER_LINKS", usage: "comma-separated Label=URL footer links", validate: validateFooterLinks},
+		{name: "APP_BRAND_PRIMARY_COLOR", usage: "primary color", validate: validateColor},
+	}
+}
+
+type Config struct {
+	getenv  func(string) string
+	values  map[string]string
+	sources map[string]string
+}
+
+func (c *Config) Get(key string) string {
+	if value, ok := c.values[key]; ok {
+		return value
+	}
+
+	return c.getenv(key)
+}
+
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range settings() {
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", s.name, redact(s, c.values[s.name]), c.sources[s.name])
+	}
+
+	return tw.Flush() //nolint:wrapcheck
+}
+
+func redact(s setting, value string) string {
+	if value == "" || !s.sensitive {
+		return value
+	}
+
+	if s.name == "APP_DB" {
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+			return redactDBURL(u)
+		}
+	}
+
+	return redacted
+}
+
+func redactDBURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if name := strings.ToLower(key); strings.Contains(name, "password") || strings.Contains(name, "secret") {
+			query[key] = []string{"xxxxx"}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type ConfigLoader struct {
+	flags *flag.FlagSet
+	path  *string
+	set   map[string]*string
+}
+
+func NewConfigLoader(flags *flag.FlagSet) *ConfigLoader {
+	loader := &ConfigLoader{
+		flags: flags,
+		path:  flags.String("config", "", "YAML or TOML config file, defaults to $"+configPathEnv),
+		set:   make(map[string]*string),
+	}
+
+	for _, s := range settings() {
+		loader.set[s.name] = flags.String(flagName(s.name), "", s.usage+", overrides $"+s.name)
+	}
+
+	return loader
+}
+
+func (l *ConfigLoader) Load(getenv func(string) string) (*Config, error) {
+	config := &Config{
+		getenv:  getenv,
+		values:  make(map[string]string),
+		sources: make(map[string]string),
+	}
+	known := make(map[string]setting)
+	for _, s := range settings() {
+		known[s.name] = s
+		config.values[s.name] = s.fallback
+		config.sources[s.name] = sourceDefault
+	}
+
+	var problems []string
+	flagged := l.flagged()
+
+	path := getenv(configPathEnv)
+	if flagged["config"] {
+		path = *l.path
+	}
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, key := range sortedKeys(values) {
+			if _, ok := known[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %q", path, configFileKey(key)))
+
+				continue
+			}
+			config.values[key], config.sources[key] = values[key], sourceFile
+		}
+	}
+
+	for _, s := range settings() {
+		value, fileValue := getenv(s.name), getenv(s.name+fileEnvSuffix)
+		switch {
+		case value != "" && fileValue != "":
+			problems = append(problems, fmt.Sprintf("%s: both %s and %s are set", s.name, s.name, s.name+fileEnvSuffix))
+		case value != "":
+			config.values[s.name], config.sources[s.name] = value, sourceEnv
+		case fileValue != "":
+			bytes, err := os.ReadFile(fileValue)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", s.name+fileEnvSuffix, err))
+
+				continue
+			}
+			config.values[s.name] = strings.TrimRight(string(bytes), "\r\n")
+			config.sources[s.name] = sourceEnv + " " + s.name + fileEnvSuffix
+		}
+	}
+
+	for _, s := range settings() {
+		if flagged[flagName(s.name)] {
+			config.values[s.name], config.sources[s.name] = *l.set[s.name], sourceFlag
+		}
+	}
+
+	for _, s := range settings() {
+		if value := config.values[s.name]; value != "" && s.validate != nil {
+			if err := s.validate(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s (%s): %s", s.name, config.sources[s.name], err))
+			}
+		}
+	}
+	problems = append(problems, validateCombinations(config.values)...)
+
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+
+	return config, nil
+}
+
+func (l *ConfigLoader) flagged() map[string]bool {
+	flagged := make(map[string]bool)
+	l.flags.Visit(func(f *flag.Flag) { flagged[f.Name] = true })
+
+	return flagged
+}
+
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, "APP_")), "_", "-")
+}
+
+func configFileKey(name string) string {
+	return strings.ToLower(strings.TrimPrefix(name, "APP_"))
+}
+
+func readConfigFile(path string) (map[string]string, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var raw map[string]any
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bytes, &raw)
+	case ".toml":
+		err = toml.Unmarshal(bytes, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flattenConfig(values, "APP", raw)
+
+	return values, nil
+}
+
+func flattenConfig(values map[string]string, prefix string, raw map[string]any) {
+	for key, value := range raw {
+		name := prefix + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+
+		switch value := value.(type) {
+		case map[string]any:
+			flattenConfig(values, name, value)
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func validateCombinations(values map[string]string) []string {
+	var problems []string
+
+	if (values["APP_TLS_CERT"] == "") != (values["APP_TLS_KEY"] == "") {
+		problems = append(problems, "APP_TLS_CERT and APP_TLS_KEY must be set together")
+	}
+	if values["APP_TLS_REDIRECT_LISTEN"] != "" && values["APP_TLS_CERT"] == "" {
+		problems = append(problems, "APP_TLS_REDIRECT_LISTEN requires APP_TLS_CERT and APP_TLS_KEY")
+	}
+	if values["APP_SNAPSHOT_PATH"] != "" && values["APP_SNAPSHOT_KEY"] == "" {
+		problems = append(problems, "APP_SNAPSHOT_PATH requires APP_SNAPSHOT_KEY")
+	}
+	if values["APP_BRAND_LOGO"] != "" && values["APP_ASSETS_DIR"] == "" {
+		problems = append(problems, "APP_BRAND_LOGO requires APP_ASSETS_DIR")
+	}
+
+	return problems
+}
+
+var (
+	errUnsupportedDB = errors.New("unsupported storage, expected postgres://, redis://, rediss://, file:// or empty for memory")
+	errInvalidValue  = errors.New("invalid value")
+)
+
+func validateOneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(allowed, value) {
+			return fmt.Errorf("%w %q, expected one of %s", errInvalidValue, value, strings.Join(allowed, ", "))
+		}
+
+		return nil
+	}
+}
+
+func validateDuration(minimum time.Duration) func(string) error {
+	return func(value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w %q, expected a duration like 30s or 5m", errInvalidValue, value)
+		}
+		if duration < minimum {
+			return fmt.Errorf("%w %q, must be positive", errInvalidValue, value)
+		}
+
+		return nil
+	}
+}
+
+func validateInt(minimum int64) func(string) error {
+	return func(value string) error {
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || number < minimum {
+			return fmt.Errorf("%w %q, expected an integer of at least %d", errInvalidValue, value, minimum)
+		}
+
+		return nil
+	}
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("%w %q, expected true or false", errInvalidValue, value)
+	}
+
+	return nil
+}
+
+func validateFileMode(value string) error {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("%w %q, expected an octal file mode like 0660", errInvalidValue, value)
+	}
+
+	return nil
+}
+
+func validateListen(value string) error {
+	if path, ok := strings.CutPrefix(value, unixScheme); ok {
+		if path == "" {
+			return fmt.Errorf("%w %q, expected a socket path", errInvalidValue, value)
+		}
+
+		return nil
+	}
+
+	return validateHostPort(value)
+}
+
+func validateHostPort(value string) error {
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return fmt.Errorf("%w %q, expected host:port", errInvalidValue, value)
+	}
+
+	return nil
+}
+
+func validateDB(value string) error {
+	if strings.HasPrefix(value, "file://") {
+		_, err := fileStoragePath(value)
+
+		return err
+	}
+
+	for _, prefix := range []string{"postgres://", "postgresql://", "redis://", "rediss://"} {
+		if strings.HasPrefix(value, prefix) {
+			return nil
+		}
+	}
+
+	scheme, _, _ := strings.Cut(value, ":")
+
+	return fmt.Errorf("%w: %q", errUnsupportedDB, scheme)
+}
+
+func validateSnapshotKey(value string) error {
+	if _, err := secret.ParseSnapshotKey(value); err != nil {
+		return fmt.Errorf("%w, expected 32 random bytes, base64 encoded", errInvalidValue)
+	}
+
+	return nil
+}
+
+func validateCSRFKey(value string) error {
+	if key, err := base64.StdEncoding.DecodeString(value); err != nil || len(key) < csrfKeySize {
+		return fmt.Errorf("%w, expected at least %d random bytes, base64 encoded", errInvalidValue, csrfKeySize)
+	}
+
+	return nil
+}
+
+func validatePublicURL(value string) error {
+	if _, ok := proxy.ParsePublicURL(value); !ok {
+		return fmt.Errorf("%w %q, expected an absolute http(s) URL", errInvalidValue, value)
+	}
+
+	return nil
+}
+
+func validatePrefixes(value string) error {
+	for _, raw := range strings.Split(value, ",") {
+		if _, ok := proxy.ParsePrefix(strings.TrimSpace(raw)); !ok {
+			return fmt.Errorf("%w %q, expected an IP address or CIDR", errInvalidValue, strings.TrimSpace(raw))
+		}
+	}
+
+	return nil
+}
+
+func validateColor(value string) error {
+	if !hexColorPattern.MatchString(value) {
+		return fmt.Errorf("%w %q, expected a hex color like #0055aa", errInvalidValue, value)
+	}
+
+	return nil
+}
+
+func validateFooterLinks(value string) error {
+	for _, raw := range strings.Split(value, ",") {
+		label, link, ok := strings.Cut(raw, "=")
+		u, err := url.Parse(strings.TrimSpace(link))
+		if !ok || strings.TrimSpace(label) == "" || err != nil || !isFooterLink(u) {
+			return fmt.Errorf("%w %q, expected Label=URL with an http(s) or absolute path URL", errInvalidValue, raw)
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,21 @@
 package app
 
 import (
+	"cmp"
+	"encoding/base64"
 	"io"
 	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pugkong/sharesecrets/html"
+	"github.com/pugkong/sharesecrets/proxy"
+	"github.com/pugkong/sharesecrets/secret"
 )
 
 type env struct {
@@ -50,6 +62,228 @@ func (e *env) LogOutput() io.Writer {
 	}
 }
 
+func (e *env) LogRedactAttrs() []string {
+	var attrs []string
+	for _, attr := range strings.Split(e.getenv("APP_LOG_REDACT_ATTRS"), ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
+
 func (e *env) DB() string {
 	return e.getenv("APP_DB")
 }
+
+func (e *env) OTLPEndpoint() string {
+	return e.getenv("APP_OTLP_ENDPOINT")
+}
+
+func (e *env) DrainPeriod() time.Duration {
+	period, err := time.ParseDuration(e.getenv("APP_DRAIN_PERIOD"))
+	if err != nil || period < 0 {
+		return 0
+	}
+
+	return period
+}
+
+func (e *env) APITokens() []string {
+	var tokens []string
+	for _, token := range strings.Split(e.getenv("APP_API_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+func (e *env) PgCleanup() secret.PgCleanupConfig {
+	config := secret.PgCleanupConfig{
+		BatchSize: 1000,
+		Pause:     100 * time.Millisecond,
+		Cutoff:    30 * time.Second,
+	}
+
+	if size, err := strconv.Atoi(e.getenv("APP_PG_CLEANUP_BATCH_SIZE")); err == nil && size > 0 {
+		config.BatchSize = size
+	}
+	if pause, err := time.ParseDuration(e.getenv("APP_PG_CLEANUP_PAUSE")); err == nil && pause >= 0 {
+		config.Pause = pause
+	}
+	if cutoff, err := time.ParseDuration(e.getenv("APP_PG_CLEANUP_CUTOFF")); err == nil && cutoff > 0 {
+		config.Cutoff = cutoff
+	}
+
+	return config
+}
+
+func (e *env) MemoryLimits() secret.InMemoryLimits {
+	limits := secret.InMemoryLimits{
+		MaxSecrets: 100_000,
+		MaxBytes:   256 << 20,
+	}
+
+	if secrets, err := strconv.Atoi(e.getenv("APP_MEMORY_MAX_SECRETS")); err == nil && secrets >= 0 {
+		limits.MaxSecrets = secrets
+	}
+	if bytes, err := strconv.ParseInt(e.getenv("APP_MEMORY_MAX_BYTES"), 10, 64); err == nil && bytes >= 0 {
+		limits.MaxBytes = bytes
+	}
+
+	return limits
+}
+
+func (e *env) SnapshotPath() string {
+	return e.getenv("APP_SNAPSHOT_PATH")
+}
+
+func (e *env) SnapshotKey() string {
+	return e.getenv("APP_SNAPSHOT_KEY")
+}
+
+func (e *env) SnapshotInterval() time.Duration {
+	interval, err := time.ParseDuration(e.getenv("APP_SNAPSHOT_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+
+	return interval
+}
+
+func (e *env) CleanupSchedule() secret.CleanupSchedule {
+	schedule := secret.CleanupSchedule{
+		Interval: time.Minute,
+		Jitter:   10 * time.Second,
+	}
+
+	if interval, err := time.ParseDuration(e.getenv("APP_CLEANUP_INTERVAL")); err == nil && interval > 0 {
+		schedule.Interval = interval
+	}
+	if jitter, err := time.ParseDuration(e.getenv("APP_CLEANUP_JITTER")); err == nil && jitter >= 0 {
+		schedule.Jitter = jitter
+	}
+
+	return schedule
+}
+
+func (e *env) TLSCert() string {
+	return e.getenv("APP_TLS_CERT")
+}
+
+func (e *env) TLSKey() string {
+	return e.getenv("APP_TLS_KEY")
+}
+
+func (e *env) TLSRedirectListen() string {
+	return e.getenv("APP_TLS_REDIRECT_LISTEN")
+}
+
+func (e *env) ListenMode() os.FileMode {
+	mode, err := strconv.ParseUint(e.getenv("APP_LISTEN_MODE"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0o660
+	}
+
+	return os.FileMode(mode)
+}
+
+func (e *env) SystemdListenFDs() int {
+	if pid, err := strconv.Atoi(e.getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return 0
+	}
+
+	fds, err := strconv.Atoi(e.getenv("LISTEN_FDS"))
+	if err != nil || fds < 0 {
+		return -1
+	}
+
+	return fds
+}
+
+func (e *env) PublicURL() *url.URL {
+	publicURL, ok := proxy.ParsePublicURL(e.getenv("APP_PUBLIC_URL"))
+	if !ok {
+		return nil
+	}
+
+	return publicURL
+}
+
+func (e *env) TrustedProxies() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, raw := range strings.Split(e.getenv("APP_TRUSTED_PROXIES"), ",") {
+		if prefix, ok := proxy.ParsePrefix(strings.TrimSpace(raw)); ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+func (e *env) HSTS() html.HSTSConfig {
+	config := html.HSTSConfig{}
+
+	if maxAge, err := time.ParseDuration(e.getenv("APP_HSTS_MAX_AGE")); err == nil && maxAge > 0 {
+		config.MaxAge = maxAge
+	}
+
+	if include, err := strconv.ParseBool(e.getenv("APP_HSTS_INCLUDE_SUBDOMAINS")); err == nil {
+		config.IncludeSubdomains = include
+	}
+
+	return config
+}
+
+func (e *env) CSRFKey() []byte {
+	key, err := base64.StdEncoding.DecodeString(e.getenv("APP_CSRF_KEY"))
+	if err != nil || len(key) < csrfKeySize {
+		return nil
+	}
+
+	return key
+}
+
+func (e *env) AssetsDir() string {
+	return e.getenv("APP_ASSETS_DIR")
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (e *env) Branding() html.Branding {
+	branding := html.Branding{
+		Name: cmp.Or(strings.TrimSpace(e.getenv("APP_BRAND_NAME")), "ShareSecrets"),
+		Logo: strings.TrimPrefix(e.getenv("APP_BRAND_LOGO"), "/"),
+	}
+
+	if color := e.getenv("APP_BRAND_PRIMARY_COLOR"); hexColorPattern.MatchString(color) {
+		branding.PrimaryColor = color
+	}
+
+	for _, raw := range strings.Split(e.getenv("APP_BRAND_FOOTER_LINKS"), ",") {
+		label, link, ok := strings.Cut(raw, "=")
+		if label = strings.TrimSpace(label); !ok || label == "" {
+			continue
+		}
+
+		u, err := url.Parse(strings.TrimSpace(link))
+		if err != nil || !isFooterLink(u) {
+			continue
+		}
+
+		branding.FooterLinks = append(branding.FooterLinks, html.Link{Label: label, URL: u.String()})
+	}
+
+	return branding
+}
+
+func isFooterLink(u *url.URL) bool {
+	if u.Scheme == "http" || u.Scheme == "https" {
+		return u.Host != ""
+	}
+
+	return u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/")
+}
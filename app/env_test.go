@@ -1,11 +1,17 @@
 package app
 
 import (
+	"encoding/base64"
 	"io"
 	"log/slog"
+	"net/netip"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/pugkong/sharesecrets/html"
+	"github.com/pugkong/sharesecrets/secret"
 	"github.com/stretchr/testify/require"
 )
 
@@ -131,6 +137,32 @@ func TestEnv_LogOutput(t *testing.T) {
 	}
 }
 
+func TestEnv_LogRedactAttrs(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected []string
+	}{
+		"default": {
+			env:      nil,
+			expected: nil,
+		},
+		"multiple attrs": {
+			env:      map[string]string{"APP_LOG_REDACT_ATTRS": "clientIP, ,user"},
+			expected: []string{"clientIP", "user"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.LogRedactAttrs()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
 func TestEnv_DB(t *testing.T) {
 	tests := map[string]struct {
 		env      map[string]string
@@ -156,3 +188,425 @@ func TestEnv_DB(t *testing.T) {
 		})
 	}
 }
+
+func TestEnv_OTLPEndpoint(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected string
+	}{
+		"default": {
+			env:      nil,
+			expected: "",
+		},
+		"custom value": {
+			env:      map[string]string{"APP_OTLP_ENDPOINT": "http://localhost:4318"},
+			expected: "http://localhost:4318",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.OTLPEndpoint()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_DrainPeriod(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected time.Duration
+	}{
+		"default": {
+			env:      nil,
+			expected: 0,
+		},
+		"custom value": {
+			env:      map[string]string{"APP_DRAIN_PERIOD": "15s"},
+			expected: 15 * time.Second,
+		},
+		"invalid value": {
+			env:      map[string]string{"APP_DRAIN_PERIOD": "soon"},
+			expected: 0,
+		},
+		"negative value": {
+			env:      map[string]string{"APP_DRAIN_PERIOD": "-1s"},
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.DrainPeriod()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_APITokens(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected []string
+	}{
+		"default": {
+			env:      nil,
+			expected: nil,
+		},
+		"single token": {
+			env:      map[string]string{"APP_API_TOKENS": "secret"},
+			expected: []string{"secret"},
+		},
+		"multiple tokens": {
+			env:      map[string]string{"APP_API_TOKENS": "first, second,,"},
+			expected: []string{"first", "second"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.APITokens()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_PgCleanup(t *testing.T) {
+	defaults := secret.PgCleanupConfig{BatchSize: 1000, Pause: 100 * time.Millisecond, Cutoff: 30 * time.Second}
+
+	tests := map[string]struct {
+		env      map[string]string
+		expected secret.PgCleanupConfig
+	}{
+		"default": {
+			env:      nil,
+			expected: defaults,
+		},
+		"custom values": {
+			env: map[string]string{
+				"APP_PG_CLEANUP_BATCH_SIZE": "500",
+				"APP_PG_CLEANUP_PAUSE":      "0s",
+				"APP_PG_CLEANUP_CUTOFF":     "1m",
+			},
+			expected: secret.PgCleanupConfig{BatchSize: 500, Pause: 0, Cutoff: time.Minute},
+		},
+		"invalid values": {
+			env: map[string]string{
+				"APP_PG_CLEANUP_BATCH_SIZE": "0",
+				"APP_PG_CLEANUP_PAUSE":      "-1s",
+				"APP_PG_CLEANUP_CUTOFF":     "never",
+			},
+			expected: defaults,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.PgCleanup()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_MemoryLimits(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected secret.InMemoryLimits
+	}{
+		"default": {
+			env:      nil,
+			expected: secret.InMemoryLimits{MaxSecrets: 100_000, MaxBytes: 256 << 20},
+		},
+		"custom values": {
+			env:      map[string]string{"APP_MEMORY_MAX_SECRETS": "10", "APP_MEMORY_MAX_BYTES": "1024"},
+			expected: secret.InMemoryLimits{MaxSecrets: 10, MaxBytes: 1024},
+		},
+		"unlimited": {
+			env:      map[string]string{"APP_MEMORY_MAX_SECRETS": "0", "APP_MEMORY_MAX_BYTES": "0"},
+			expected: secret.InMemoryLimits{},
+		},
+		"invalid values": {
+			env:      map[string]string{"APP_MEMORY_MAX_SECRETS": "-1", "APP_MEMORY_MAX_BYTES": "1GB"},
+			expected: secret.InMemoryLimits{MaxSecrets: 100_000, MaxBytes: 256 << 20},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.MemoryLimits()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_SnapshotInterval(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected time.Duration
+	}{
+		"default": {
+			env:      nil,
+			expected: time.Minute,
+		},
+		"custom value": {
+			env:      map[string]string{"APP_SNAPSHOT_INTERVAL": "30s"},
+			expected: 30 * time.Second,
+		},
+		"invalid value": {
+			env:      map[string]string{"APP_SNAPSHOT_INTERVAL": "often"},
+			expected: time.Minute,
+		},
+		"zero value": {
+			env:      map[string]string{"APP_SNAPSHOT_INTERVAL": "0s"},
+			expected: time.Minute,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.SnapshotInterval()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_CleanupSchedule(t *testing.T) {
+	defaults := secret.CleanupSchedule{Interval: time.Minute, Jitter: 10 * time.Second}
+
+	tests := map[string]struct {
+		env      map[string]string
+		expected secret.CleanupSchedule
+	}{
+		"default": {
+			env:      nil,
+			expected: defaults,
+		},
+		"custom values": {
+			env:      map[string]string{"APP_CLEANUP_INTERVAL": "5m", "APP_CLEANUP_JITTER": "0s"},
+			expected: secret.CleanupSchedule{Interval: 5 * time.Minute},
+		},
+		"invalid values": {
+			env:      map[string]string{"APP_CLEANUP_INTERVAL": "0s", "APP_CLEANUP_JITTER": "-1s"},
+			expected: defaults,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.CleanupSchedule()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_ListenMode(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected os.FileMode
+	}{
+		"default":      {env: nil, expected: 0o660},
+		"custom value": {env: map[string]string{"APP_LISTEN_MODE": "0600"}, expected: 0o600},
+		"invalid":      {env: map[string]string{"APP_LISTEN_MODE": "rw"}, expected: 0o660},
+		"too large":    {env: map[string]string{"APP_LISTEN_MODE": "7777"}, expected: 0o660},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.ListenMode()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_SystemdListenFDs(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := map[string]struct {
+		env      map[string]string
+		expected int
+	}{
+		"default":       {env: nil, expected: 0},
+		"activated":     {env: map[string]string{"LISTEN_PID": pid, "LISTEN_FDS": "1"}, expected: 1},
+		"other process": {env: map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}, expected: 0},
+		"invalid fds":   {env: map[string]string{"LISTEN_PID": pid, "LISTEN_FDS": "x"}, expected: -1},
+		"multiple fds":  {env: map[string]string{"LISTEN_PID": pid, "LISTEN_FDS": "2"}, expected: 2},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.SystemdListenFDs()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_PublicURL(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected string
+	}{
+		"default": {env: nil, expected: ""},
+		"valid":   {env: map[string]string{"APP_PUBLIC_URL": "https://secrets.example.com"}, expected: "https://secrets.example.com"},
+		"invalid": {env: map[string]string{"APP_PUBLIC_URL": "secrets.example.com"}, expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.PublicURL()
+
+			if test.expected == "" {
+				require.Nil(t, actual)
+			} else {
+				require.Equal(t, test.expected, actual.String())
+			}
+		})
+	}
+}
+
+func TestEnv_TrustedProxies(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected []netip.Prefix
+	}{
+		"default": {env: nil, expected: nil},
+		"custom values": {
+			env: map[string]string{"APP_TRUSTED_PROXIES": "10.0.0.0/8, 127.0.0.1,invalid"},
+			expected: []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("127.0.0.1/32"),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.TrustedProxies()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_HSTS(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected html.HSTSConfig
+	}{
+		"default": {env: nil, expected: html.HSTSConfig{}},
+		"custom values": {
+			env:      map[string]string{"APP_HSTS_MAX_AGE": "8760h", "APP_HSTS_INCLUDE_SUBDOMAINS": "true"},
+			expected: html.HSTSConfig{MaxAge: 8760 * time.Hour, IncludeSubdomains: true},
+		},
+		"invalid values": {
+			env:      map[string]string{"APP_HSTS_MAX_AGE": "-1h", "APP_HSTS_INCLUDE_SUBDOMAINS": "maybe"},
+			expected: html.HSTSConfig{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.HSTS()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_CSRFKey(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := map[string]struct {
+		env      map[string]string
+		expected []byte
+	}{
+		"default":   {env: nil, expected: nil},
+		"valid":     {env: map[string]string{"APP_CSRF_KEY": base64.StdEncoding.EncodeToString(key)}, expected: key},
+		"too short": {env: map[string]string{"APP_CSRF_KEY": base64.StdEncoding.EncodeToString(key[:16])}, expected: nil},
+		"invalid":   {env: map[string]string{"APP_CSRF_KEY": "not base64!"}, expected: nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.CSRFKey()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEnv_Branding(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected html.Branding
+	}{
+		"default": {env: nil, expected: html.Branding{Name: "ShareSecrets"}},
+		"custom values": {
+			env: map[string]string{
+				"APP_BRAND_NAME":          "Acme Secrets",
+				"APP_BRAND_LOGO":          "/logo.svg",
+				"APP_BRAND_PRIMARY_COLOR": "#0055aa",
+				"APP_BRAND_FOOTER_LINKS":  "Privacy policy=https://example.com/privacy?lang=en, Imprint=/imprint",
+			},
+			expected: html.Branding{
+				Name:         "Acme Secrets",
+				Logo:         "logo.svg",
+				PrimaryColor: "#0055aa",
+				FooterLinks: []html.Link{
+					{Label: "Privacy policy", URL: "https://example.com/privacy?lang=en"},
+					{Label: "Imprint", URL: "/imprint"},
+				},
+			},
+		},
+		"invalid values": {
+			env: map[string]string{
+				"APP_BRAND_PRIMARY_COLOR": "blue",
+				"APP_BRAND_FOOTER_LINKS":  "Script=javascript:alert(1),Relative=//evil.example.com,=https://example.com,Missing",
+			},
+			expected: html.Branding{Name: "ShareSecrets"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newEnv(mapenv(test.env))
+
+			actual := env.Branding()
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
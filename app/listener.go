@@ -0,0 +1,78 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	unixScheme     = "unix://"
+	systemdFirstFD = 3
+)
+
+type listenConfig struct {
+	Address    string
+	Mode       os.FileMode
+	SystemdFDs int
+}
+
+var errSystemdFDs = errors.New("systemd socket activation must pass exactly one socket")
+
+func listen(config listenConfig) (net.Listener, error) {
+	switch config.SystemdFDs {
+	case 0:
+	case 1:
+		return systemdListener(systemdFirstFD)
+	default:
+		return nil, fmt.Errorf("%w, got LISTEN_FDS=%d", errSystemdFDs, config.SystemdFDs)
+	}
+
+	if path, ok := strings.CutPrefix(config.Address, unixScheme); ok {
+		return unixListener(path, config.Mode)
+	}
+
+	listener, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("listen tcp: %w", err)
+	}
+
+	return listener, nil
+}
+
+func unixListener(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen unix: %w", err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		return nil, errors.Join(fmt.Errorf("chmod socket: %w", err), listener.Close())
+	}
+
+	return listener, nil
+}
+
+func systemdListener(fd uintptr) (net.Listener, error) {
+	file := os.NewFile(fd, "systemd-socket")
+	if file == nil {
+		return nil, fmt.Errorf("systemd socket: invalid file descriptor %d", fd)
+	}
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket: %w", err)
+	}
+
+	return listener, nil
+}
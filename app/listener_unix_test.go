@@ -0,0 +1,113 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serveHello(t *testing.T, listener net.Listener) {
+	t.Helper()
+
+	server := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "hello")
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+}
+
+func getBody(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestListen(t *testing.T) {
+	t.Run("it listens on unix socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		listener, err := listen(listenConfig{Address: "unix://" + path, Mode: 0o600})
+		require.NoError(t, err)
+		serveHello(t, listener)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+		require.Equal(t, "hello", getBody(t, client, "http://unix/"))
+	})
+
+	t.Run("it refuses to replace regular file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+		_, err := listen(listenConfig{Address: "unix://" + path, Mode: 0o600})
+		require.Error(t, err)
+	})
+
+	t.Run("it listens on tcp address", func(t *testing.T) {
+		listener, err := listen(listenConfig{Address: "127.0.0.1:0"})
+		require.NoError(t, err)
+		serveHello(t, listener)
+
+		require.Equal(t, "hello", getBody(t, http.DefaultClient, "http://"+listener.Addr().String()+"/"))
+	})
+
+	t.Run("it rejects unexpected systemd socket count", func(t *testing.T) {
+		for _, fds := range []int{-1, 2} {
+			_, err := listen(listenConfig{Address: "127.0.0.1:0", SystemdFDs: fds})
+			require.ErrorIs(t, err, errSystemdFDs)
+		}
+	})
+
+	t.Run("it uses inherited systemd socket", func(t *testing.T) {
+		inherited, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = inherited.Close() })
+
+		file, err := inherited.(*net.TCPListener).File()
+		require.NoError(t, err)
+
+		fd, err := syscall.Dup(int(file.Fd()))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		listener, err := systemdListener(uintptr(fd))
+		require.NoError(t, err)
+		serveHello(t, listener)
+
+		require.Equal(t, "hello", getBody(t, http.DefaultClient, "http://"+listener.Addr().String()+"/"))
+	})
+}
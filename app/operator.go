@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/pugkong/sharesecrets/secret"
+)
+
+var errPrivateStorage = errors.New("in-memory storage is private to the server process, use postgres, redis or file storage")
+
+type migrator interface {
+	Init(ctx context.Context) error
+}
+
+func migrate(ctx context.Context, store secret.Store) error {
+	m, ok := store.(migrator)
+	if !ok {
+		return nil
+	}
+
+	if err := m.Init(ctx); err != nil {
+		return fmt.Errorf("secret store migration: %w", err)
+	}
+
+	return nil
+}
+
+func (a *App) Migrate(ctx context.Context, out io.Writer) error {
+	return a.withStore(ctx, false, func(ctx context.Context, logger *slog.Logger, store secret.Store) error {
+		if _, ok := store.(migrator); !ok {
+			_, err := fmt.Fprintln(out, "Nothing to migrate")
+
+			return err //nolint:wrapcheck
+		}
+
+		if err := migrate(ctx, store); err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to migrate secret store", slog.String("error", err.Error()))
+
+			return err
+		}
+
+		_, err := fmt.Fprintln(out, "Migrations applied")
+
+		return err //nolint:wrapcheck
+	})
+}
+
+func (a *App) Cleanup(ctx context.Context, out io.Writer) error {
+	return a.withStore(ctx, true, func(ctx context.Context, logger *slog.Logger, store secret.Store) error {
+		removed, err := store.Cleanup(ctx)
+		if err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to cleanup secrets", slog.String("error", err.Error()))
+
+			return fmt.Errorf("cleanup: %w", err)
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "Expired secrets removed", slog.Int("removed", removed))
+
+		_, err = fmt.Fprintf(out, "Removed %d expired secrets\n", removed)
+
+		return err //nolint:wrapcheck
+	})
+}
+
+func (a *App) Stats(ctx context.Context, out io.Writer) error {
+	return a.withStore(ctx, true, func(ctx context.Context, logger *slog.Logger, store secret.Store) error {
+		stats, err := store.Stats(ctx)
+		if err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to collect secrets stats", slog.String("error", err.Error()))
+
+			return fmt.Errorf("stats: %w", err)
+		}
+
+		_, err = fmt.Fprintf(out, "Active secrets:  %d\nExpired secrets: %d\nTotal bytes:     %d\n",
+			stats.Active,
+			stats.Expired,
+			stats.Bytes,
+		)
+
+		return err //nolint:wrapcheck
+	})
+}
+
+func (a *App) Purge(ctx context.Context, out io.Writer) error {
+	return a.withStore(ctx, true, func(ctx context.Context, logger *slog.Logger, store secret.Store) error {
+		count, err := store.Purge(ctx)
+		if err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to purge secrets", slog.String("error", err.Error()))
+
+			return fmt.Errorf("purge: %w", err)
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, "All secrets purged", slog.Int("count", count))
+
+		_, err = fmt.Fprintf(out, "Purged %d secrets\n", count)
+
+		return err //nolint:wrapcheck
+	})
+}
+
+func (a *App) withStore(
+	ctx context.Context,
+	requireShared bool,
+	fn func(context.Context, *slog.Logger, secret.Store) error,
+) error {
+	logger := a.newLogger()
+
+	storage, err := a.openStorage(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if requireShared && !storage.shared {
+		logger.LogAttrs(ctx, slog.LevelError, "Refusing to run against in-memory storage")
+
+		return errPrivateStorage
+	}
+
+	return fn(ctx, logger, storage.Store)
+}
@@ -2,42 +2,101 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/pugkong/sharesecrets/api"
+	"github.com/pugkong/sharesecrets/health"
 	"github.com/pugkong/sharesecrets/html"
+	"github.com/pugkong/sharesecrets/i18n"
 	"github.com/pugkong/sharesecrets/logger"
+	"github.com/pugkong/sharesecrets/proxy"
 	"github.com/pugkong/sharesecrets/secret"
+	"github.com/pugkong/sharesecrets/tracing"
 )
 
+const (
+	certReloadInterval = 10 * time.Second
+	csrfKeySize        = 32
+)
+
+type serverConfig struct {
+	Listen         string
+	DrainPeriod    time.Duration
+	APITokens      []string
+	TLSCert        string
+	TLSKey         string
+	RedirectListen string
+	Proxy          proxy.Config
+	HSTS           html.HSTSConfig
+	CSRFKey        []byte
+	AssetsDir      string
+	Branding       html.Branding
+}
+
 type server struct {
-	logger  *slog.Logger
-	secrets *secret.Service
-	server  *http.Server
+	logger   *slog.Logger
+	secrets  *secret.Service
+	health   *health.Handler
+	config   serverConfig
+	listener net.Listener
+	server   *http.Server
+	redirect *http.Server
+	certs    *certReloader
 }
 
-func newServer(logger *slog.Logger, secrets *secret.Service, listen string) *server {
+func newServer(
+	logger *slog.Logger,
+	secrets *secret.Service,
+	health *health.Handler,
+	listener net.Listener,
+	config serverConfig,
+) *server {
 	return &server{
-		logger:  logger,
-		secrets: secrets,
+		logger:   logger,
+		secrets:  secrets,
+		health:   health,
+		config:   config,
+		listener: listener,
 		server: &http.Server{
 			ReadHeaderTimeout: time.Second,
-			Addr:              listen,
 		},
 	}
 }
 
 func (s *server) Init(ctx context.Context) error {
-	assets, err := html.MakeAssets()
+	assets, err := html.MakeAssets(s.config.AssetsDir)
+	if err == nil {
+		err = assets.AddBranding(s.config.Branding)
+	}
 	if err != nil {
 		s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server assets initialization error", slog.String("error", err.Error()))
 
 		return fmt.Errorf("assets initialization: %w", err)
 	}
 
+	csrfKey := s.config.CSRFKey
+	if len(csrfKey) == 0 {
+		csrfKey = make([]byte, csrfKeySize)
+		if _, err := rand.Read(csrfKey); err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server csrf key generation error", slog.String("error", err.Error()))
+
+			return fmt.Errorf("csrf key generation: %w", err)
+		}
+	}
+
+	locales, err := i18n.LoadBundle()
+	if err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server locales initialization error", slog.String("error", err.Error()))
+
+		return fmt.Errorf("locales initialization: %w", err)
+	}
+
 	renderer := html.NewRenderer(s.logger)
 	secretHandler := secret.NewHandler(s.secrets, renderer)
 
@@ -47,30 +106,112 @@ func (s *server) Init(ctx context.Context) error {
 
 	var handler http.Handler = mux
 	handler = html.NewRecoverMiddleware(s.logger, renderer).Handler(handler)
-	handler = html.NewCSRFMiddleware(s.logger, renderer)(handler)
+	handler = html.NewCSRFMiddleware(s.logger, renderer, csrfKey)(handler)
 	handler = html.NewAssetsMiddleware(s.logger, assets)(handler)
+	handler = html.NewBrandingMiddleware(s.config.Branding)(handler)
 	handler = html.NewParseFormMiddleware(renderer)(handler)
+	handler = html.NewSecurityHeadersMiddleware(s.logger, s.config.HSTS)(handler)
+	handler = i18n.NewMiddleware(locales)(handler)
+
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", s.health.Live)
+	root.HandleFunc("GET /readyz", s.health.Ready)
+	root.Handle("/", s.observe(handler))
+
+	if len(s.config.APITokens) > 0 {
+		responder := api.NewResponder(s.logger)
+		apiHandler := secret.NewAPIHandler(s.secrets, responder)
+
+		apiMux := http.NewServeMux()
+		apiMux.HandleFunc("POST /api/v1/secrets", apiHandler.Share)
+		apiMux.HandleFunc("POST /api/v1/secrets/{key}/open", apiHandler.Open)
+
+		var handler http.Handler = apiMux
+		handler = api.NewRecoverMiddleware(s.logger, responder)(handler)
+		handler = api.NewBearerAuthMiddleware(responder, s.config.APITokens)(handler)
+		root.Handle("/api/v1/", s.observe(handler))
+	}
+
+	s.server.Handler = root
+
+	if s.config.TLSCert == "" && s.config.TLSKey == "" {
+		return nil
+	}
+
+	certs, err := newCertReloader(s.logger, s.config.TLSCert, s.config.TLSKey, certReloadInterval)
+	if err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server TLS initialization error", slog.String("error", err.Error()))
+
+		return fmt.Errorf("tls initialization: %w", err)
+	}
+	s.certs = certs
+	s.server.TLSConfig = newTLSConfig(certs)
+
+	if s.config.RedirectListen != "" {
+		s.redirect = &http.Server{
+			ReadHeaderTimeout: time.Second,
+			Addr:              s.config.RedirectListen,
+			Handler:           newRedirectHandler(s.config.Proxy.PublicURL, s.config.Listen),
+		}
+	}
+
+	return nil
+}
+
+func (s *server) observe(handler http.Handler) http.Handler {
 	handler = logger.NewRequestLoggerMiddleware(s.logger).Handler(handler)
+	handler = proxy.NewMiddleware(s.config.Proxy)(handler)
 	handler = logger.NewRequestIDMiddleware(s.logger).Handler(handler)
-	s.server.Handler = handler
+	handler = tracing.NewMiddleware()(handler)
 
-	return nil
+	return handler
 }
 
 func (s *server) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancelCause(ctx)
 	go func() {
-		s.logger.InfoContext(ctx, "HTTP server started on "+s.server.Addr)
-		if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		addr := s.listener.Addr().String()
+		if s.certs != nil {
+			s.logger.InfoContext(ctx, "HTTPS server started on "+addr)
+			err = s.server.ServeTLS(s.listener, "", "")
+		} else {
+			s.logger.InfoContext(ctx, "HTTP server started on "+addr)
+			err = s.server.Serve(s.listener)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
 			s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server serve error", slog.String("error", err.Error()))
 			cancel(err)
 		}
 	}()
+	if s.certs != nil {
+		go func() { _ = s.certs.Watch(ctx) }()
+	}
+	if s.redirect != nil {
+		go func() {
+			s.logger.InfoContext(ctx, "HTTP redirect server started on "+s.redirect.Addr)
+			if err := s.redirect.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				s.logger.LogAttrs(ctx, slog.LevelError, "HTTP redirect server serve error", slog.String("error", err.Error()))
+				cancel(err)
+			}
+		}()
+	}
 	<-ctx.Done()
 
 	serveErr := fmt.Errorf("http serve: %w", context.Cause(ctx))
 
+	if s.config.DrainPeriod > 0 && errors.Is(context.Cause(ctx), context.Canceled) {
+		s.logger.InfoContext(ctx, "Draining HTTP server for "+s.config.DrainPeriod.String())
+		s.health.Drain()
+		time.Sleep(s.config.DrainPeriod)
+	}
+
 	s.logger.InfoContext(ctx, "Shutting down HTTP server")
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(context.WithoutCancel(ctx)); err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "HTTP redirect server shutdown error", slog.String("error", err.Error()))
+		}
+	}
 	if err := s.server.Shutdown(context.WithoutCancel(ctx)); err != nil {
 		s.logger.LogAttrs(ctx, slog.LevelError, "HTTP server shutdown error", slog.String("error", err.Error()))
 
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package app
+
+import "os"
+
+func notifyCleanupSignal(chan<- os.Signal) {}
+
+func notifyReloadSignal(chan<- os.Signal) {}
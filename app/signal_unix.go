@@ -0,0 +1,17 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func notifyCleanupSignal(signals chan<- os.Signal) {
+	signal.Notify(signals, syscall.SIGUSR1)
+}
+
+func notifyReloadSignal(signals chan<- os.Signal) {
+	signal.Notify(signals, syscall.SIGHUP)
+}
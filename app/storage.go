@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pugkong/sharesecrets/health"
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/redis/go-redis/v9"
+)
+
+type storage struct {
+	Store       secret.Store
+	Checks      map[string]health.Check
+	Snapshotter *secret.Snapshotter
+	shared      bool
+	close       func()
+}
+
+func (s *storage) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+func (a *App) openStorage(ctx context.Context, logger *slog.Logger) (*storage, error) {
+	switch db := a.env.DB(); {
+	case strings.HasPrefix(db, "postgres"):
+		return a.openPgStorage(ctx, logger, db)
+	case strings.HasPrefix(db, "redis"):
+		return a.openRedisStorage(ctx, logger, db)
+	case strings.HasPrefix(db, "file://"):
+		return a.openFileStorage(ctx, logger, db)
+	default:
+		return a.openMemoryStorage(ctx, logger)
+	}
+}
+
+func (a *App) openMemoryStorage(ctx context.Context, logger *slog.Logger) (*storage, error) {
+	store := secret.NewInMemoryStore(logger.With(slog.String("layer", "store")), a.env.MemoryLimits())
+	logger.InfoContext(ctx, "Using in-memory storage")
+
+	path := a.env.SnapshotPath()
+	if path == "" {
+		return &storage{Store: store}, nil
+	}
+
+	key, err := secret.ParseSnapshotKey(a.env.SnapshotKey())
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize snapshots", slog.String("error", err.Error()))
+
+		return nil, fmt.Errorf("snapshots initialization: %w", err)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "Snapshots enabled", slog.String("path", path))
+
+	snapshotter := secret.NewSnapshotter(
+		logger.With(slog.String("layer", "snapshots")),
+		store,
+		path,
+		key,
+		a.env.SnapshotInterval(),
+		time.Now,
+	)
+
+	return &storage{
+		Store:       store,
+		Snapshotter: snapshotter,
+		close: func() {
+			if err := snapshotter.Close(); err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "Failed to close snapshots", slog.String("error", err.Error()))
+			}
+		},
+	}, nil
+}
+
+func (a *App) openPgStorage(ctx context.Context, logger *slog.Logger, db string) (*storage, error) {
+	var pool *pgxpool.Pool
+	config, err := pgxpool.ParseConfig(db)
+	if err == nil {
+		config.ConnConfig.Tracer = otelpgx.NewTracer()
+		pool, err = pgxpool.NewWithConfig(ctx, config)
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize postgres pool",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("postgres pool initialization: %w", err)
+	}
+	logger.InfoContext(ctx, "Using postgres storage")
+
+	return &storage{
+		Store:  secret.NewPgStore(pool, a.env.PgCleanup()),
+		Checks: map[string]health.Check{"postgres": pool.Ping},
+		shared: true,
+		close:  pool.Close,
+	}, nil
+}
+
+func (a *App) openRedisStorage(ctx context.Context, logger *slog.Logger, db string) (*storage, error) {
+	options, err := redis.ParseURL(db)
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to initialize redis client",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("redis client initialization: %w", err)
+	}
+	client := redis.NewClient(options)
+	logger.InfoContext(ctx, "Using redis storage")
+
+	return &storage{
+		Store: secret.NewRedisStore(client),
+		Checks: map[string]health.Check{"redis": func(ctx context.Context) error {
+			return client.Ping(ctx).Err() //nolint:wrapcheck
+		}},
+		shared: true,
+		close: func() {
+			if err := client.Close(); err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "Failed to close redis client", slog.String("error", err.Error()))
+			}
+		},
+	}, nil
+}
+
+var errFileDBPath = errors.New("file storage URL must have an absolute path and no host, e.g. file:///var/lib/sharesecrets/db")
+
+func fileStoragePath(db string) (string, error) {
+	u, err := url.Parse(db)
+	if err != nil {
+		return "", fmt.Errorf("parse file storage URL: %w", err)
+	}
+	if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return "", errFileDBPath
+	}
+
+	return u.Path, nil
+}
+
+func (a *App) openFileStorage(ctx context.Context, logger *slog.Logger, db string) (*storage, error) {
+	var store *secret.BoltStore
+	path, err := fileStoragePath(db)
+	if err == nil {
+		store, err = secret.OpenBoltStore(path)
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to open file storage",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("file storage initialization: %w", err)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "Using file storage", slog.String("path", path))
+
+	return &storage{
+		Store:  store,
+		shared: true,
+		close: func() {
+			if err := store.Close(); err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "Failed to close file storage", slog.String("error", err.Error()))
+			}
+		},
+	}, nil
+}
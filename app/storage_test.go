@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_OpenStorage(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := loggertest.New()
+
+	t.Run("it uses in-memory storage by default", func(t *testing.T) {
+		app := New(mapenv(nil))
+
+		storage, err := app.openStorage(ctx, logger)
+		require.NoError(t, err)
+		defer storage.Close()
+
+		require.IsType(t, &secret.InMemoryStore{}, storage.Store)
+		require.Empty(t, storage.Checks)
+		require.Nil(t, storage.Snapshotter)
+	})
+
+	t.Run("it uses redis storage", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		app := New(mapenv(map[string]string{"APP_DB": "redis://" + server.Addr() + "/0"}))
+
+		storage, err := app.openStorage(ctx, logger)
+		require.NoError(t, err)
+		defer storage.Close()
+
+		require.IsType(t, &secret.RedisStore{}, storage.Store)
+		require.NoError(t, storage.Checks["redis"](ctx))
+	})
+
+	t.Run("it reports invalid redis url", func(t *testing.T) {
+		app := New(mapenv(map[string]string{"APP_DB": "redis://localhost:6379/not-a-db"}))
+
+		_, err := app.openStorage(ctx, logger)
+		require.ErrorContains(t, err, "redis client initialization")
+	})
+
+	t.Run("it uses file storage", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.db")
+		app := New(mapenv(map[string]string{"APP_DB": "file://" + path}))
+
+		storage, err := app.openStorage(ctx, logger)
+		require.NoError(t, err)
+		defer storage.Close()
+
+		require.IsType(t, &secret.BoltStore{}, storage.Store)
+		require.FileExists(t, path)
+	})
+
+	t.Run("it reports unusable file storage path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.db")
+		app := New(mapenv(map[string]string{"APP_DB": "file://" + path + "/nested/db"}))
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+		_, err := app.openStorage(ctx, logger)
+		require.ErrorContains(t, err, "file storage initialization")
+	})
+
+	t.Run("it rejects file storage URL with host", func(t *testing.T) {
+		app := New(mapenv(map[string]string{"APP_DB": "file://data/secrets.db"}))
+
+		_, err := app.openStorage(ctx, logger)
+		require.ErrorIs(t, err, errFileDBPath)
+		require.NoFileExists(t, "/secrets.db")
+	})
+
+	t.Run("it snapshots in-memory storage", func(t *testing.T) {
+		app := New(mapenv(map[string]string{
+			"APP_SNAPSHOT_PATH": filepath.Join(t.TempDir(), "snapshot"),
+			"APP_SNAPSHOT_KEY":  base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		}))
+
+		storage, err := app.openStorage(ctx, logger)
+		require.NoError(t, err)
+		defer storage.Close()
+
+		require.IsType(t, &secret.InMemoryStore{}, storage.Store)
+		require.NotNil(t, storage.Snapshotter)
+	})
+
+	t.Run("it reports invalid snapshot key", func(t *testing.T) {
+		app := New(mapenv(map[string]string{
+			"APP_SNAPSHOT_PATH": filepath.Join(t.TempDir(), "snapshot"),
+			"APP_SNAPSHOT_KEY":  "short",
+		}))
+
+		_, err := app.openStorage(ctx, logger)
+		require.ErrorContains(t, err, "snapshots initialization")
+	})
+}
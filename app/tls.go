@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+func newTLSConfig(reloader *certReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		CurvePreferences: []tls.CurveID{
+			tls.X25519,
+			tls.CurveP256,
+		},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+type certReloader struct {
+	logger   *slog.Logger
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	lock     sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+func newCertReloader(logger *slog.Logger, certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	reloader := &certReloader{
+		logger:   logger,
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.cert, nil
+}
+
+func (r *certReloader) Reload() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cert = &cert
+	r.modTimes = modTimes
+
+	return nil
+}
+
+func (r *certReloader) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	signals := make(chan os.Signal, 1)
+	notifyReloadSignal(signals)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ticker.C:
+			if r.changed() {
+				r.reload(ctx, "file change")
+			}
+		case sig := <-signals:
+			r.reload(ctx, sig.String())
+		case <-ctx.Done():
+			return fmt.Errorf("tls certificate watch: %w", ctx.Err())
+		}
+	}
+}
+
+func (r *certReloader) reload(ctx context.Context, reason string) {
+	if err := r.Reload(); err != nil {
+		r.logger.LogAttrs(ctx, slog.LevelError, "Failed to reload TLS certificate",
+			slog.String("reason", reason),
+			slog.String("error", err.Error()),
+		)
+
+		return
+	}
+	r.logger.LogAttrs(ctx, slog.LevelInfo, "TLS certificate reloaded", slog.String("reason", reason))
+}
+
+func (r *certReloader) changed() bool {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return false
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return modTimes != r.modTimes
+}
+
+func (r *certReloader) statFiles() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, fmt.Errorf("stat tls file: %w", err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+func newRedirectHandler(publicURL *url.URL, listen string) http.Handler {
+	_, port, _ := net.SplitHostPort(listen)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicURL != nil {
+			http.Redirect(w, r, strings.TrimSuffix(publicURL.String(), "/")+r.URL.RequestURI(), http.StatusMovedPermanently)
+
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
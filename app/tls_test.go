@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/pugkong/sharesecrets/proxy"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSelfSignedCert(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func servedCommonName(t *testing.T, url string) string {
+	t.Helper()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		DisableKeepAlives: true,
+	}}
+
+	response, err := client.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	return response.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+func startTLSServer(t *testing.T, reloader *certReloader) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler:           http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		TLSConfig:         newTLSConfig(reloader),
+	}
+	go func() { _ = server.ServeTLS(listener, "", "") }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func TestCertReloader(t *testing.T) {
+	logger, _ := loggertest.New()
+
+	t.Run("it reloads certificate when files change", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writeSelfSignedCert(t, dir, "first")
+
+		reloader, err := newCertReloader(logger, certFile, keyFile, time.Millisecond)
+		require.NoError(t, err)
+		url := startTLSServer(t, reloader)
+		require.Equal(t, "first", servedCommonName(t, url))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- reloader.Watch(ctx) }()
+
+		writeSelfSignedCert(t, dir, "second")
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, future, future))
+
+		require.Eventually(t, func() bool {
+			return servedCommonName(t, url) == "second"
+		}, time.Second, 5*time.Millisecond)
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("it keeps serving previous certificate when reload fails", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writeSelfSignedCert(t, dir, "first")
+
+		reloader, err := newCertReloader(logger, certFile, keyFile, time.Hour)
+		require.NoError(t, err)
+		url := startTLSServer(t, reloader)
+
+		require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+		require.Error(t, reloader.Reload())
+		require.Equal(t, "first", servedCommonName(t, url))
+	})
+
+	t.Run("it reports missing files", func(t *testing.T) {
+		dir := t.TempDir()
+
+		_, err := newCertReloader(logger, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), time.Hour)
+		require.Error(t, err)
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := map[string]struct {
+		publicURL string
+		listen    string
+		target    string
+		location  string
+	}{
+		"default https port": {
+			listen:   "0.0.0.0:443",
+			target:   "http://example.com/key?x=1",
+			location: "https://example.com/key?x=1",
+		},
+		"custom https port": {
+			listen:   ":8443",
+			target:   "http://example.com:8080/key",
+			location: "https://example.com:8443/key",
+		},
+		"public url": {
+			publicURL: "https://secrets.example.com/",
+			listen:    ":8443",
+			target:    "http://example.com:8080/key?x=1",
+			location:  "https://secrets.example.com/key?x=1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			publicURL, _ := proxy.ParsePublicURL(test.publicURL)
+
+			w := httptest.NewRecorder()
+			newRedirectHandler(publicURL, test.listen).ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			require.Equal(t, http.StatusMovedPermanently, w.Code)
+			require.Equal(t, test.location, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertReloader_Signal(t *testing.T) {
+	logger, _ := loggertest.New()
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir, "first")
+
+	reloader, err := newCertReloader(logger, certFile, keyFile, time.Hour)
+	require.NoError(t, err)
+	url := startTLSServer(t, reloader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- reloader.Watch(ctx) }()
+
+	writeSelfSignedCert(t, dir, "second")
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+
+		return servedCommonName(t, url) == "second"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type IO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string, stdio IO, getenv func(string) string) error
+}
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+var errUsage = errors.New("invalid usage")
+
+func commands() []command {
+	return []command{
+		{name: "serve", description: "Start the web server (default)", run: serve},
+		{name: "share", description: "Share a message read from stdin and print its link", run: share},
+		{name: "open", description: "Open a secret link and print its message", run: open},
+		{name: "migrate", description: "Apply the storage schema", run: migrate},
+		{name: "cleanup", description: "Remove expired secrets once", run: cleanup},
+		{name: "stats", description: "Print counts and total size of stored secrets", run: stats},
+		{name: "purge", description: "Remove every stored secret, requires --all", run: purge},
+		{name: "config", description: "Print the effective configuration with secrets redacted", run: config},
+	}
+}
+
+func Run(ctx context.Context, args []string, stdio IO, getenv func(string) string) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	for _, command := range commands() {
+		if command.name != name {
+			continue
+		}
+
+		err := command.run(ctx, args, stdio, getenv)
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(stdio.Stderr, "%s: %s\n", name, err)
+
+			return exitUsage
+		default:
+			fmt.Fprintf(stdio.Stderr, "%s: %s\n", name, err)
+
+			return exitError
+		}
+	}
+
+	usage(stdio.Stderr)
+	if name == "help" || name == "-h" || name == "--help" {
+		return exitOK
+	}
+
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("Usage: sharesecrets <command> [arguments]\n\nCommands:\n")
+	for _, command := range commands() {
+		fmt.Fprintf(&b, "  %-8s %s\n", command.name, command.description)
+	}
+	b.WriteString("\nRun 'sharesecrets <command> -h' for command arguments.\n")
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+func newFlagSet(name string, stdio IO) *flag.FlagSet {
+	flags := flag.NewFlagSet("sharesecrets "+name, flag.ContinueOnError)
+	flags.SetOutput(stdio.Stderr)
+
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err //nolint:wrapcheck
+		}
+
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	return nil
+}
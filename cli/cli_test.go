@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/api"
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "token"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := secret.NewService(
+		logger,
+		secret.NewSecretboxEncryptor(logger),
+		secret.NewInMemoryStore(logger, secret.InMemoryLimits{}),
+		secret.CleanupSchedule{Interval: time.Minute},
+		time.Now,
+	)
+	responder := api.NewResponder(logger)
+	handler := secret.NewAPIHandler(service, responder)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/secrets", handler.Share)
+	mux.HandleFunc("POST /api/v1/secrets/{key}/open", handler.Open)
+
+	server := httptest.NewServer(api.NewBearerAuthMiddleware(responder, []string{testToken})(mux))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func runCLI(t *testing.T, env map[string]string, stdin string, args ...string) result {
+	t.Helper()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	stdio := IO{Stdin: strings.NewReader(stdin), Stdout: stdout, Stderr: stderr}
+	code := Run(context.Background(), args, stdio, func(key string) string { return env[key] })
+
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "passphrase.txt")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	return file
+}
+
+func TestShareAndOpen(t *testing.T) {
+	server := newTestServer(t)
+	env := map[string]string{"SHARESECRETS_URL": server.URL, "SHARESECRETS_TOKEN": testToken}
+
+	t.Run("it shares and opens secret", func(t *testing.T) {
+		passphraseFile := writeFile(t, "passphrase\n")
+
+		shared := runCLI(t, env, "message\n", "share", "--expire", "1h", "--passphrase-file", passphraseFile)
+		require.Equal(t, 0, shared.code, shared.stderr)
+		require.Regexp(t, "^"+server.URL+"/[0-9a-f]{32}\n$", shared.stdout)
+
+		link := strings.TrimSpace(shared.stdout)
+		opened := runCLI(t, env, "", "open", "--passphrase-file", passphraseFile, link)
+		require.Equal(t, 0, opened.code, opened.stderr)
+		require.Equal(t, "message\n", opened.stdout)
+
+		opened = runCLI(t, env, "", "open", "--passphrase-file", passphraseFile, link)
+		require.Equal(t, 1, opened.code)
+		require.Equal(t, "open: open secret: Secret not found (not_found)\n", opened.stderr)
+	})
+
+	t.Run("it uses flags over env", func(t *testing.T) {
+		shared := runCLI(t, nil, "message", "share", "--server", server.URL, "--token", testToken)
+		require.Equal(t, 0, shared.code, shared.stderr)
+
+		opened := runCLI(t, nil, "", "open", "--token", testToken, strings.TrimSpace(shared.stdout))
+		require.Equal(t, 0, opened.code, opened.stderr)
+		require.Equal(t, "message\n", opened.stdout)
+	})
+
+	t.Run("it reports invalid passphrase", func(t *testing.T) {
+		shared := runCLI(t, env, "message", "share", "--passphrase-file", writeFile(t, "passphrase"))
+		require.Equal(t, 0, shared.code, shared.stderr)
+
+		opened := runCLI(t, env, "", "open", strings.TrimSpace(shared.stdout))
+		require.Equal(t, 1, opened.code)
+		require.Equal(t, "open: open secret: Invalid passphrase (invalid_passphrase)\n", opened.stderr)
+	})
+
+	t.Run("it reports validation violations", func(t *testing.T) {
+		shared := runCLI(t, env, "message", "share", "--expire", "48h")
+		require.Equal(t, 1, shared.code)
+		require.Equal(
+			t,
+			"share: share secret: Request validation failed (validation_failed): Expire must be less than 1 day\n",
+			shared.stderr,
+		)
+	})
+
+	t.Run("it rejects sub-second expiration", func(t *testing.T) {
+		shared := runCLI(t, env, "message", "share", "--expire", "500ms")
+		require.Equal(t, 2, shared.code)
+		require.Equal(t, "share: invalid usage: --expire must be at least 1s, got 500ms\n", shared.stderr)
+	})
+
+	t.Run("it reports invalid token", func(t *testing.T) {
+		shared := runCLI(t, map[string]string{"SHARESECRETS_URL": server.URL}, "message", "share")
+		require.Equal(t, 1, shared.code)
+		require.Equal(t, "share: share secret: missing or invalid bearer token (unauthorized)\n", shared.stderr)
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("it prints usage for unknown command", func(t *testing.T) {
+		result := runCLI(t, nil, "", "unknown")
+
+		require.Equal(t, 2, result.code)
+		require.Contains(t, result.stderr, "Usage: sharesecrets <command> [arguments]")
+	})
+
+	t.Run("it prints usage on help", func(t *testing.T) {
+		result := runCLI(t, nil, "", "help")
+
+		require.Equal(t, 0, result.code)
+		require.Contains(t, result.stderr, "Usage: sharesecrets <command> [arguments]")
+	})
+
+	t.Run("it reports invalid flags", func(t *testing.T) {
+		result := runCLI(t, nil, "", "share", "--unknown")
+
+		require.Equal(t, 2, result.code)
+		require.Contains(t, result.stderr, "flag provided but not defined: -unknown")
+	})
+
+	t.Run("it reports invalid secret URL", func(t *testing.T) {
+		result := runCLI(t, nil, "", "open", "not-a-url")
+
+		require.Equal(t, 2, result.code)
+		require.Equal(t, "open: invalid usage: invalid secret URL \"not-a-url\"\n", result.stderr)
+	})
+
+	t.Run("it requires secret URL", func(t *testing.T) {
+		result := runCLI(t, nil, "", "open")
+
+		require.Equal(t, 2, result.code)
+		require.Equal(t, "open: invalid usage: expected exactly one secret URL\n", result.stderr)
+	})
+}
+
+func TestSplitSecretURL(t *testing.T) {
+	tests := map[string]struct {
+		link   string
+		server string
+		key    string
+	}{
+		"root":   {link: "https://example.com/abc", server: "https://example.com/", key: "abc"},
+		"prefix": {link: "https://example.com/secrets/abc?x=1#y", server: "https://example.com/secrets", key: "abc"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, key, err := splitSecretURL(test.link)
+
+			require.NoError(t, err)
+			require.Equal(t, test.server, server)
+			require.Equal(t, test.key, key)
+		})
+	}
+}
+
+func TestExpireAmount(t *testing.T) {
+	tests := map[time.Duration]struct {
+		amount int
+		unit   string
+	}{
+		2 * time.Hour:           {amount: 2, unit: "hours"},
+		90 * time.Minute:        {amount: 90, unit: "minutes"},
+		90 * time.Second:        {amount: 90, unit: "seconds"},
+		1500 * time.Millisecond: {amount: 2, unit: "seconds"},
+	}
+
+	for duration, test := range tests {
+		t.Run(duration.String(), func(t *testing.T) {
+			amount, unit := expireAmount(duration)
+
+			require.Equal(t, test.amount, amount)
+			require.Equal(t, test.unit, unit)
+		})
+	}
+}
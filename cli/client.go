@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pugkong/sharesecrets/api"
+	"github.com/pugkong/sharesecrets/secret"
+)
+
+type APIError struct {
+	Status int
+	api.ErrorBody
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s (%s)", e.Message, e.Code)
+	if len(e.Violations) > 0 {
+		message += ": " + strings.Join(e.Violations, "; ")
+	}
+
+	return message
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    httpClient,
+	}
+}
+
+func (c *Client) Share(ctx context.Context, request secret.APIShareRequest) (secret.APIShareResponse, error) {
+	var response secret.APIShareResponse
+	err := c.post(ctx, "/api/v1/secrets", request, &response)
+
+	return response, err
+}
+
+func (c *Client) Open(ctx context.Context, key string, request secret.APIOpenRequest) (secret.APIOpenResponse, error) {
+	var response secret.APIOpenResponse
+	err := c.post(ctx, "/api/v1/secrets/"+url.PathEscape(key)+"/open", request, &response)
+
+	return response, err
+}
+
+var errUnexpectedResponse = errors.New("unexpected response")
+
+func (c *Client) post(ctx context.Context, path string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errorResponse api.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResponse); err != nil || errorResponse.Error.Code == "" {
+			return fmt.Errorf("%w: %s", errUnexpectedResponse, resp.Status)
+		}
+
+		return &APIError{Status: resp.StatusCode, ErrorBody: errorResponse.Error}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/pugkong/sharesecrets/app"
+)
+
+func config(_ context.Context, args []string, stdio IO, getenv func(string) string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("%w: expected 'config print'", errUsage)
+	}
+
+	effective, err := loadConfig(newFlagSet("config print", stdio), args[1:], getenv)
+	if err != nil {
+		return err
+	}
+
+	return effective.Print(stdio.Stdout) //nolint:wrapcheck
+}
+
+func loadConfig(flags *flag.FlagSet, args []string, getenv func(string) string) (*app.Config, error) {
+	loader := app.NewConfigLoader(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	return loader.Load(getenv) //nolint:wrapcheck
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pugkong/sharesecrets/app"
+)
+
+func migrate(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	config, err := loadConfig(newFlagSet("migrate", stdio), args, getenv)
+	if err != nil {
+		return err
+	}
+
+	return app.New(config.Get).Migrate(ctx, stdio.Stdout) //nolint:wrapcheck
+}
+
+func cleanup(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	config, err := loadConfig(newFlagSet("cleanup", stdio), args, getenv)
+	if err != nil {
+		return err
+	}
+
+	return app.New(config.Get).Cleanup(ctx, stdio.Stdout) //nolint:wrapcheck
+}
+
+func stats(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	config, err := loadConfig(newFlagSet("stats", stdio), args, getenv)
+	if err != nil {
+		return err
+	}
+
+	return app.New(config.Get).Stats(ctx, stdio.Stdout) //nolint:wrapcheck
+}
+
+func purge(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	flags := newFlagSet("purge", stdio)
+	all := flags.Bool("all", false, "confirm removal of every stored secret")
+	config, err := loadConfig(flags, args, getenv)
+	if err != nil {
+		return err
+	}
+
+	if !*all {
+		return fmt.Errorf("%w: refusing to purge without --all", errUsage)
+	}
+
+	return app.New(config.Get).Purge(ctx, stdio.Stdout) //nolint:wrapcheck
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorCommands(t *testing.T) {
+	newEnv := func(t *testing.T) map[string]string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "secrets.db")
+		store, err := secret.OpenBoltStore(path)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		require.NoError(t, store.Save(ctx, "active", secret.NewSecret([]byte("active"), 1, time.Now().Add(time.Hour))))
+		require.NoError(t, store.Save(ctx, "expired", secret.NewSecret([]byte("expired"), 1, time.Now().Add(-time.Hour))))
+		require.NoError(t, store.Close())
+
+		return map[string]string{"APP_LOG_OUTPUT": "discard", "APP_DB": "file://" + path}
+	}
+
+	tests := map[string]struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		"migrate": {
+			args:   []string{"migrate"},
+			stdout: "Nothing to migrate\n",
+		},
+		"cleanup": {
+			args:   []string{"cleanup"},
+			stdout: "Removed 1 expired secrets\n",
+		},
+		"stats": {
+			args:   []string{"stats"},
+			stdout: "Active secrets:  1\nExpired secrets: 1\nTotal bytes:     13\n",
+		},
+		"purge": {
+			args:   []string{"purge", "--all"},
+			stdout: "Purged 2 secrets\n",
+		},
+		"purge without confirmation": {
+			args:   []string{"purge"},
+			code:   2,
+			stderr: "purge: invalid usage: refusing to purge without --all\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := runCLI(t, newEnv(t), "", test.args...)
+
+			require.Equal(t, test.code, result.code)
+			require.Equal(t, test.stdout, result.stdout)
+			require.Equal(t, test.stderr, result.stderr)
+		})
+	}
+
+	t.Run("it refuses in-memory storage", func(t *testing.T) {
+		env := map[string]string{"APP_LOG_OUTPUT": "discard"}
+
+		for _, args := range [][]string{{"cleanup"}, {"stats"}, {"purge", "--all"}} {
+			result := runCLI(t, env, "", args...)
+
+			require.Equal(t, 1, result.code)
+			require.Empty(t, result.stdout)
+			require.Equal(
+				t,
+				args[0]+": in-memory storage is private to the server process, use postgres, redis or file storage\n",
+				result.stderr,
+			)
+		}
+	})
+}
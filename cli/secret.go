@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pugkong/sharesecrets/secret"
+)
+
+const defaultServerURL = "http://127.0.0.1:8000"
+
+func share(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	flags := newFlagSet("share", stdio)
+	server := flags.String("server", cmp.Or(getenv("SHARESECRETS_URL"), defaultServerURL), "server URL, defaults to $SHARESECRETS_URL")
+	token := flags.String("token", getenv("SHARESECRETS_TOKEN"), "API token, defaults to $SHARESECRETS_TOKEN")
+	expire := flags.Duration("expire", 15*time.Minute, "secret lifetime")
+	passphraseFile := flags.String("passphrase-file", "", "read the passphrase from the file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %q", errUsage, flags.Args())
+	}
+	if *expire < time.Second {
+		return fmt.Errorf("%w: --expire must be at least 1s, got %s", errUsage, *expire)
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	message, err := io.ReadAll(stdio.Stdin)
+	if err != nil {
+		return fmt.Errorf("read message: %w", err)
+	}
+
+	amount, unit := expireAmount(*expire)
+	client := NewClient(*server, *token, http.DefaultClient)
+	response, err := client.Share(ctx, secret.APIShareRequest{
+		Message:    trimNewline(string(message)),
+		Passphrase: passphrase,
+		Expire:     secret.APIShareExpire{Amount: &amount, Unit: unit},
+	})
+	if err != nil {
+		return fmt.Errorf("share secret: %w", err)
+	}
+
+	_, err = fmt.Fprintln(stdio.Stdout, response.URL)
+
+	return err //nolint:wrapcheck
+}
+
+func open(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	flags := newFlagSet("open", stdio)
+	token := flags.String("token", getenv("SHARESECRETS_TOKEN"), "API token, defaults to $SHARESECRETS_TOKEN")
+	passphraseFile := flags.String("passphrase-file", "", "read the passphrase from the file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w: expected exactly one secret URL", errUsage)
+	}
+
+	server, key, err := splitSecretURL(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	client := NewClient(server, *token, http.DefaultClient)
+	response, err := client.Open(ctx, key, secret.APIOpenRequest{Passphrase: passphrase})
+	if err != nil {
+		return fmt.Errorf("open secret: %w", err)
+	}
+
+	_, err = fmt.Fprintln(stdio.Stdout, response.Message)
+
+	return err //nolint:wrapcheck
+}
+
+func splitSecretURL(link string) (string, string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	key := path.Base(u.Path)
+	if u.Scheme == "" || u.Host == "" || key == "/" || key == "." {
+		return "", "", fmt.Errorf("%w: invalid secret URL %q", errUsage, link)
+	}
+
+	u.Path = path.Dir(u.Path)
+	u.RawQuery, u.Fragment = "", ""
+
+	return u.String(), key, nil
+}
+
+func readPassphrase(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+
+	return trimNewline(string(bytes)), nil
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+
+	return strings.TrimSuffix(s, "\r")
+}
+
+func expireAmount(d time.Duration) (int, string) {
+	switch {
+	case d%time.Hour == 0:
+		return int(d / time.Hour), "hours"
+	case d%time.Minute == 0:
+		return int(d / time.Minute), "minutes"
+	default:
+		return int((d + time.Second - 1) / time.Second), "seconds"
+	}
+}
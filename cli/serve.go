@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pugkong/sharesecrets/app"
+)
+
+func serve(ctx context.Context, args []string, stdio IO, getenv func(string) string) error {
+	config, err := loadConfig(newFlagSet("serve", stdio), args, getenv)
+	if err != nil {
+		return err
+	}
+
+	app := app.New(config.Get)
+	if err := app.Run(ctx); !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
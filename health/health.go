@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Check func(ctx context.Context) error
+
+type Handler struct {
+	logger   *slog.Logger
+	checks   map[string]Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHandler(logger *slog.Logger, checks map[string]Check) *Handler {
+	return &Handler{
+		logger:  logger,
+		checks:  checks,
+		timeout: time.Second,
+	}
+}
+
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+}
+
+func (h *Handler) Live(w http.ResponseWriter, _ *http.Request) {
+	h.write(w, http.StatusOK, "ok")
+}
+
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		h.write(w, http.StatusServiceUnavailable, "draining")
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	var failures []string
+	for name, check := range h.checks {
+		if err := check(ctx); err != nil {
+			h.logger.LogAttrs(ctx, slog.LevelWarn, "Readiness check failed",
+				slog.String("check", name),
+				slog.String("error", err.Error()),
+			)
+			failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		slices.Sort(failures)
+		h.write(w, http.StatusServiceUnavailable, strings.Join(failures, "\n"))
+
+		return
+	}
+
+	h.write(w, http.StatusOK, "ok")
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if _, err := w.Write([]byte(body + "\n")); err != nil {
+		h.logger.LogAttrs(context.Background(), slog.LevelError, "Failed to write health response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	okCheck := func(context.Context) error { return nil }
+	failCheck := func(context.Context) error { return errors.New("connection refused") } //nolint:goerr113
+
+	serve := func(handler http.HandlerFunc) (int, string) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("it reports liveness", func(t *testing.T) {
+		handler := NewHandler(logger, map[string]Check{"db": failCheck})
+
+		status, body := serve(handler.Live)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "ok\n", body)
+	})
+
+	t.Run("it reports readiness when all checks pass", func(t *testing.T) {
+		handler := NewHandler(logger, map[string]Check{"db": okCheck, "cleanup": okCheck})
+
+		status, body := serve(handler.Ready)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "ok\n", body)
+	})
+
+	t.Run("it reports failed readiness checks", func(t *testing.T) {
+		logger, output := loggertest.New()
+		handler := NewHandler(logger, map[string]Check{"db": failCheck, "cleanup": okCheck})
+
+		status, body := serve(handler.Ready)
+
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, "db: connection refused\n", body)
+		require.Equal(
+			t,
+			[]map[string]any{
+				{"level": "WARN", "msg": "Readiness check failed", "check": "db", "error": "connection refused"},
+			},
+			output(t),
+		)
+	})
+
+	t.Run("it fails readiness while draining", func(t *testing.T) {
+		handler := NewHandler(logger, map[string]Check{"db": okCheck})
+		handler.Drain()
+
+		status, body := serve(handler.Ready)
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, "draining\n", body)
+
+		status, _ = serve(handler.Live)
+		require.Equal(t, http.StatusOK, status)
+	})
+}
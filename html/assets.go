@@ -7,11 +7,11 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"log/slog"
 	"mime"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -24,10 +24,21 @@ const assetsKey ctxKey = "assets"
 var assetFs embed.FS
 
 type asset struct {
-	Path        string
 	Name        string
 	ContentType string
 	Hash        string
+	Data        []byte
+}
+
+func newAsset(name string, data []byte) asset {
+	hash := sha256.Sum256(data)
+
+	return asset{
+		Name:        name,
+		ContentType: mime.TypeByExtension(filepath.Ext(name)),
+		Hash:        hex.EncodeToString(hash[:])[:8],
+		Data:        data,
+	}
 }
 
 type AssetMap map[string]asset
@@ -36,43 +47,43 @@ func (a AssetMap) Add(asset asset) {
 	a[asset.Name] = asset
 }
 
-func MakeAssets() (AssetMap, error) {
+func MakeAssets(overrideDir string) (AssetMap, error) {
 	assets := make(AssetMap)
 
+	dist, err := fs.Sub(assetFs, "dist")
+	if err != nil {
+		return nil, fmt.Errorf("load assets: %w", err)
+	}
+	if err := assets.load(dist); err != nil {
+		return nil, fmt.Errorf("load assets: %w", err)
+	}
+
+	if overrideDir != "" {
+		if err := assets.load(os.DirFS(overrideDir)); err != nil {
+			return nil, fmt.Errorf("load override assets from %q: %w", overrideDir, err)
+		}
+	}
+
+	return assets, nil
+}
+
+func (a AssetMap) load(fsys fs.FS) error {
 	walkFn := func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() || err != nil {
+		if err != nil || d.IsDir() {
 			return err
 		}
 
-		bytes, err := fs.ReadFile(assetFs, path)
+		bytes, err := fs.ReadFile(fsys, path)
 		if err != nil {
 			return fmt.Errorf("read %q asset: %w", path, err)
 		}
 
-		name := strings.TrimPrefix(path, "dist/")
-		contentType := mime.TypeByExtension(filepath.Ext(path))
-
-		hasher := sha256.New()
-		if _, err := hasher.Write(bytes); err != nil {
-			return fmt.Errorf("calculate asset %q hash: %w", path, err)
-		}
-		hash := hex.EncodeToString(hasher.Sum(nil))
-
-		assets.Add(asset{
-			Path:        path,
-			Name:        name,
-			ContentType: contentType,
-			Hash:        hash[:8],
-		})
+		a.Add(newAsset(path, bytes))
 
 		return nil
 	}
 
-	if err := fs.WalkDir(assetFs, ".", walkFn); err != nil {
-		return nil, fmt.Errorf("load assets: %w", err)
-	}
-
-	return assets, nil
+	return fs.WalkDir(fsys, ".", walkFn) //nolint:wrapcheck
 }
 
 func NewAssetsMiddleware(logger *slog.Logger, assets AssetMap) func(http.Handler) http.Handler {
@@ -101,21 +112,9 @@ func NewAssetsMiddleware(logger *slog.Logger, assets AssetMap) func(http.Handler
 				return
 			}
 
-			f, err := assetFs.Open(asset.Path)
-			if err != nil {
-				logger.LogAttrs(r.Context(), slog.LevelError, "Failed to open asset",
-					slog.String("asset", asset.Path),
-					slog.String("error", err.Error()),
-				)
-				w.WriteHeader(http.StatusInternalServerError)
-
-				return
-			}
-			defer f.Close()
-
-			if _, err := io.Copy(w, f); err != nil {
+			if _, err := w.Write(asset.Data); err != nil {
 				logger.LogAttrs(r.Context(), slog.LevelError, "Failed to deliver asset",
-					slog.String("asset", asset.Path),
+					slog.String("asset", asset.Name),
 					slog.String("error", err.Error()),
 				)
 			}
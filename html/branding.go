@@ -0,0 +1,137 @@
+package html
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	brandingKey     ctxKey = "branding"
+	brandStylesheet string = "brand.css"
+	defaultName     string = "ShareSecrets"
+)
+
+var (
+	errInvalidColor = errors.New("invalid color")
+	errLogoMissing  = errors.New("logo asset not found")
+)
+
+type Link struct {
+	Label string
+	URL   string
+}
+
+type Branding struct {
+	Name         string
+	Logo         string
+	FooterLinks  []Link
+	PrimaryColor string
+}
+
+func (a AssetMap) AddBranding(branding Branding) error {
+	if branding.Logo != "" {
+		if _, ok := a[branding.Logo]; !ok {
+			return fmt.Errorf("%w: %q", errLogoMissing, branding.Logo)
+		}
+	}
+
+	if branding.PrimaryColor == "" {
+		return nil
+	}
+
+	stylesheet, err := brandingStylesheet(branding.PrimaryColor)
+	if err != nil {
+		return err
+	}
+	a.Add(newAsset(brandStylesheet, stylesheet))
+
+	return nil
+}
+
+func NewBrandingMiddleware(branding Branding) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), brandingKey, branding)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func brandingFromContext(ctx context.Context) Branding {
+	branding, _ := ctx.Value(brandingKey).(Branding)
+	if branding.Name == "" {
+		branding.Name = defaultName
+	}
+
+	return branding
+}
+
+func brandingStylesheet(color string) ([]byte, error) {
+	r, g, b, err := parseHexColor(color)
+	if err != nil {
+		return nil, err
+	}
+
+	content := "100% 0 0"
+	if relativeLuminance(r, g, b) > 0.4 {
+		content = "0% 0 0"
+	}
+
+	return []byte(fmt.Sprintf(":root,[data-theme]{--p:%s;--pc:%s}\n", oklch(r, g, b), content)), nil
+}
+
+func parseHexColor(color string) (float64, float64, float64, error) {
+	hex, ok := strings.CutPrefix(color, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if !ok || len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("%w: %q", errInvalidColor, color)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %q", errInvalidColor, color)
+	}
+
+	return float64(value>>16&0xff) / 255, float64(value>>8&0xff) / 255, float64(value&0xff) / 255, nil
+}
+
+func linear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func relativeLuminance(r, g, b float64) float64 {
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+func oklch(r, g, b float64) string {
+	r, g, b = linear(r), linear(g), linear(b)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	lightness := 0.2104542553*l + 0.7936177850*m - 0.0040720468*s
+	a := 1.9779984951*l - 2.4285922050*m + 0.4505937099*s
+	bb := 0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+
+	chroma := math.Hypot(a, bb)
+	hue := math.Atan2(bb, a) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+
+	return fmt.Sprintf("%.2f%% %.4f %.2f", lightness*100, chroma, hue)
+}
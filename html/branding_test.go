@@ -0,0 +1,96 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrandingStylesheet(t *testing.T) {
+	tests := map[string]struct {
+		color    string
+		expected string
+	}{
+		"dark color":  {color: "#ff0000", expected: ":root,[data-theme]{--p:62.80% 0.2577 29.23;--pc:100% 0 0}\n"},
+		"light color": {color: "#ff0", expected: ":root,[data-theme]{--p:96.80% 0.2110 109.77;--pc:0% 0 0}\n"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stylesheet, err := brandingStylesheet(test.color)
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(stylesheet))
+		})
+	}
+
+	t.Run("it rejects invalid color", func(t *testing.T) {
+		_, err := brandingStylesheet("red")
+
+		require.ErrorIs(t, err, errInvalidColor)
+	})
+}
+
+func TestMakeAssets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.svg"), []byte("<svg/>"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("custom"), 0o600))
+
+	assets, err := MakeAssets(dir)
+	require.NoError(t, err)
+
+	require.Equal(t, "image/svg+xml", assets["logo.svg"].ContentType)
+	require.Equal(t, "custom", string(assets["robots.txt"].Data))
+	require.Contains(t, assets, "favicon.ico")
+
+	t.Run("it adds branding stylesheet", func(t *testing.T) {
+		err := assets.AddBranding(Branding{Logo: "logo.svg", PrimaryColor: "#123456"})
+
+		require.NoError(t, err)
+		require.Contains(t, assets, brandStylesheet)
+		require.Equal(t, "text/css; charset=utf-8", assets[brandStylesheet].ContentType)
+	})
+
+	t.Run("it requires logo asset", func(t *testing.T) {
+		err := assets.AddBranding(Branding{Logo: "missing.png"})
+
+		require.ErrorIs(t, err, errLogoMissing)
+	})
+
+	t.Run("it reports missing override directory", func(t *testing.T) {
+		_, err := MakeAssets(filepath.Join(dir, "missing"))
+
+		require.Error(t, err)
+	})
+}
+
+func TestLayout_Branding(t *testing.T) {
+	assets, err := MakeAssets("")
+	require.NoError(t, err)
+	assets.Add(newAsset("logo.png", []byte("logo")))
+
+	branding := Branding{
+		Name:        "Acme Secrets",
+		Logo:        "logo.png",
+		FooterLinks: []Link{{Label: "Privacy policy", URL: "https://example.com/privacy"}},
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, assetsKey, assets)
+	ctx = context.WithValue(ctx, csrfKey, "token")
+	ctx = context.WithValue(ctx, nonceKey, "nonce")
+	ctx = context.WithValue(ctx, brandingKey, branding)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Layout("Page").Render(ctx, out))
+
+	require.Contains(t, out.String(), "<title>Page · Acme Secrets</title>")
+	require.Contains(t, out.String(), `src="/logo.png?v=`+assets["logo.png"].Hash+`"`)
+	require.Contains(t, out.String(), `href="https://example.com/privacy"`)
+	require.Contains(t, out.String(), ">Privacy policy</a>")
+	require.NotContains(t, out.String(), brandStylesheet)
+}
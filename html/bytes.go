@@ -0,0 +1,17 @@
+package html
+
+import (
+	"context"
+	"html/template"
+	"io"
+
+	"github.com/a-h/templ"
+)
+
+func Bytes(value []byte) templ.Component {
+	return templ.ComponentFunc(func(_ context.Context, w io.Writer) error {
+		template.HTMLEscape(w, value)
+
+		return nil
+	})
+}
@@ -0,0 +1,17 @@
+package html
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytes(t *testing.T) {
+	var b strings.Builder
+
+	err := Bytes([]byte(`<script>alert("x")</script> & 'y'`)).Render(context.Background(), &b)
+	require.NoError(t, err)
+	require.Equal(t, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &#39;y&#39;", b.String())
+}
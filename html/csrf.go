@@ -2,36 +2,48 @@ package html
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
-	"crypto/subtle"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/pugkong/sharesecrets/proxy"
 )
 
 const (
 	csrfKey        ctxKey = "csrf"
 	csrfHeaderName string = "X-CSRF-Token"
+	csrfFieldName  string = "_csrf"
 )
 
 var errInvalidCSRFToken = errors.New("invalid csrf token")
 
-func NewCSRFMiddleware(logger *slog.Logger, renderer *Renderer) func(http.Handler) http.Handler {
+func NewCSRFMiddleware(logger *slog.Logger, renderer *Renderer, key []byte) func(http.Handler) http.Handler {
 	const cookieName = "csrf"
 
-	generateToken := func(ctx context.Context) string {
-		const tokenBytes = 16
+	generateSession := func(ctx context.Context) string {
+		const sessionBytes = 16
 
-		bytes := make([]byte, tokenBytes)
+		bytes := make([]byte, sessionBytes)
 		if _, err := rand.Read(bytes); err != nil {
-			logger.LogAttrs(ctx, slog.LevelError, "Failed to generate csrf token", slog.String("error", err.Error()))
+			logger.LogAttrs(ctx, slog.LevelError, "Failed to generate csrf session", slog.String("error", err.Error()))
 		}
 
 		return hex.EncodeToString(bytes)
 	}
 
+	signSession := func(session string) string {
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(session))
+
+		return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodHead || r.Method == http.MethodOptions || r.Method == http.MethodTrace {
@@ -42,23 +54,33 @@ func NewCSRFMiddleware(logger *slog.Logger, renderer *Renderer) func(http.Handle
 
 			cookie, err := r.Cookie(cookieName)
 			if r.Method == http.MethodGet && errors.Is(err, http.ErrNoCookie) {
-				cookie = &http.Cookie{
-					Name:     cookieName,
-					Value:    generateToken(r.Context()),
-					HttpOnly: true,
-				}
+				cookie, err = &http.Cookie{Value: generateSession(r.Context())}, nil
 			}
 
-			if r.Method != http.MethodGet && subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Header.Get(csrfHeaderName))) == 0 {
-				renderer.UserError(r.Context(), w, errInvalidCSRFToken)
+			if r.Method != http.MethodGet {
+				token := r.Header.Get(csrfHeaderName)
+				if token == "" {
+					token = r.PostFormValue(csrfFieldName)
+				}
 
-				return
-			}
+				if err != nil || !hmac.Equal([]byte(signSession(cookie.Value)), []byte(token)) {
+					renderer.UserError(r.Context(), w, errInvalidCSRFToken)
 
-			cookie.Expires = time.Now().Add(time.Hour)
-			http.SetCookie(w, cookie)
+					return
+				}
+			}
 
-			ctx := context.WithValue(r.Context(), csrfKey, cookie.Value)
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    cookie.Value,
+				Path:     "/",
+				Expires:  time.Now().Add(time.Hour),
+				HttpOnly: true,
+				Secure:   proxy.IsHTTPS(r),
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			ctx := context.WithValue(r.Context(), csrfKey, signSession(cookie.Value))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 
@@ -0,0 +1,112 @@
+package html
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	logger, _ := loggertest.New()
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	handler := func(key []byte) http.Handler {
+		return NewCSRFMiddleware(logger, NewRenderer(logger), key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _ := csrfToken(r.Context())
+			_, _ = w.Write([]byte(token))
+		}))
+	}
+
+	issueFor := func(t *testing.T, r *http.Request) (*http.Cookie, string) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		handler(key).ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+
+		return cookies[0], w.Body.String()
+	}
+
+	issue := func(t *testing.T) (*http.Cookie, string) {
+		t.Helper()
+
+		return issueFor(t, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	post := func(handler http.Handler, cookie *http.Cookie, header string, form url.Values) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			r.Header.Set(csrfHeaderName, header)
+		}
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("it issues strict session cookie", func(t *testing.T) {
+		cookie, token := issue(t)
+
+		require.NotEmpty(t, token)
+		require.NotEqual(t, cookie.Value, token)
+		require.False(t, cookie.Secure)
+		require.True(t, cookie.HttpOnly)
+		require.Equal(t, "/", cookie.Path)
+		require.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	})
+
+	t.Run("it marks session cookie secure over https", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.TLS = &tls.ConnectionState{}
+
+		cookie, _ := issueFor(t, r)
+
+		require.True(t, cookie.Secure)
+	})
+
+	t.Run("it accepts token from header", func(t *testing.T) {
+		cookie, token := issue(t)
+
+		require.Equal(t, http.StatusOK, post(handler(key), cookie, token, nil))
+	})
+
+	t.Run("it accepts token from form field", func(t *testing.T) {
+		cookie, token := issue(t)
+
+		require.Equal(t, http.StatusOK, post(handler(key), cookie, "", url.Values{csrfFieldName: {token}}))
+	})
+
+	t.Run("it rejects token of another session", func(t *testing.T) {
+		cookie, _ := issue(t)
+		_, token := issue(t)
+
+		require.Equal(t, http.StatusBadRequest, post(handler(key), cookie, token, nil))
+	})
+
+	t.Run("it rejects token signed with another key", func(t *testing.T) {
+		cookie, token := issue(t)
+
+		require.Equal(t, http.StatusBadRequest, post(handler([]byte("another key")), cookie, token, nil))
+	})
+
+	t.Run("it rejects missing token or cookie", func(t *testing.T) {
+		cookie, token := issue(t)
+
+		require.Equal(t, http.StatusBadRequest, post(handler(key), cookie, "", nil))
+		require.Equal(t, http.StatusBadRequest, post(handler(key), nil, token, nil))
+	})
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func TestRecoverMiddleware(t *testing.T) {
-	assets, err := MakeAssets()
+	assets, err := MakeAssets("")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -19,6 +19,7 @@ func TestRecoverMiddleware(t *testing.T) {
 	renderContext := context.Background()
 	renderContext = context.WithValue(renderContext, assetsKey, assets)
 	renderContext = context.WithValue(renderContext, csrfKey, "token")
+	renderContext = context.WithValue(renderContext, nonceKey, "nonce")
 
 	t.Run("it recovers panic and logs it", func(t *testing.T) {
 		logger, logs := loggertest.New()
@@ -12,7 +12,7 @@ import (
 )
 
 func TestRenderer(t *testing.T) {
-	assets, err := MakeAssets()
+	assets, err := MakeAssets("")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -20,6 +20,7 @@ func TestRenderer(t *testing.T) {
 	renderContext := context.Background()
 	renderContext = context.WithValue(renderContext, assetsKey, assets)
 	renderContext = context.WithValue(renderContext, csrfKey, "token")
+	renderContext = context.WithValue(renderContext, nonceKey, "nonce")
 
 	tests := map[string]struct {
 		render     func(*Renderer, http.ResponseWriter)
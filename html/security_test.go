@@ -0,0 +1,62 @@
+package html
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	serve := func(hsts HSTSConfig) (*httptest.ResponseRecorder, string) {
+		logger, _ := loggertest.New()
+
+		var nonce string
+		handler := NewSecurityHeadersMiddleware(logger, hsts)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			nonce, _ = cspNonce(r.Context())
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w, nonce
+	}
+
+	t.Run("it sets security headers with request nonce", func(t *testing.T) {
+		w, nonce := serve(HSTSConfig{})
+
+		require.NotEmpty(t, nonce)
+		require.Equal(
+			t,
+			"default-src 'none'; "+
+				"script-src 'nonce-"+nonce+"' 'strict-dynamic'; "+
+				"style-src 'self'; "+
+				"img-src 'self'; "+
+				"connect-src 'self'; "+
+				"form-action 'self'; "+
+				"frame-ancestors 'none'; "+
+				"base-uri 'none'",
+			w.Header().Get("Content-Security-Policy"),
+		)
+		require.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+		require.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("it generates new nonce per request", func(t *testing.T) {
+		_, first := serve(HSTSConfig{})
+		_, second := serve(HSTSConfig{})
+
+		require.NotEqual(t, first, second)
+	})
+
+	t.Run("it sets hsts when configured", func(t *testing.T) {
+		w, _ := serve(HSTSConfig{MaxAge: 365 * 24 * time.Hour, IncludeSubdomains: true})
+
+		require.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	})
+}
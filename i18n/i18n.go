@@ -0,0 +1,237 @@
+package i18n
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pugkong/sharesecrets/proxy"
+)
+
+const (
+	DefaultLocale = "en"
+
+	nameKey    = "locale.name"
+	cookieName = "lang"
+	queryParam = "lang"
+)
+
+type ctxKey string
+
+const localizerKey ctxKey = "localizer"
+
+//go:embed locales/*.json
+var localesFS embed.FS
+
+var errDefaultLocaleMissing = errors.New("default locale catalog is missing")
+
+type Message struct {
+	ID     string
+	Params []any
+}
+
+func Msg(id string, params ...any) Message {
+	return Message{ID: id, Params: params}
+}
+
+type Catalog map[string]string
+
+type Locale struct {
+	Tag  string
+	Name string
+}
+
+type Bundle struct {
+	catalogs map[string]Catalog
+	locales  []Locale
+}
+
+var loadDefault = sync.OnceValues(func() (*Bundle, error) {
+	return loadBundle(localesFS)
+})
+
+func LoadBundle() (*Bundle, error) {
+	return loadDefault()
+}
+
+func loadBundle(fsys fs.FS) (*Bundle, error) {
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("list catalogs: %w", err)
+	}
+
+	bundle := &Bundle{catalogs: make(map[string]Catalog, len(files))}
+	for _, file := range files {
+		bytes, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read catalog %q: %w", file, err)
+		}
+
+		var catalog Catalog
+		if err := json.Unmarshal(bytes, &catalog); err != nil {
+			return nil, fmt.Errorf("parse catalog %q: %w", file, err)
+		}
+
+		tag := strings.TrimSuffix(path.Base(file), ".json")
+		bundle.catalogs[tag] = catalog
+		bundle.locales = append(bundle.locales, Locale{Tag: tag, Name: cmp.Or(catalog[nameKey], tag)})
+	}
+
+	if _, ok := bundle.catalogs[DefaultLocale]; !ok {
+		return nil, errDefaultLocaleMissing
+	}
+
+	return bundle, nil
+}
+
+func (b *Bundle) Locales() []Locale {
+	return b.locales
+}
+
+func (b *Bundle) Catalog(tag string) (Catalog, bool) {
+	catalog, ok := b.catalogs[tag]
+
+	return catalog, ok
+}
+
+func (b *Bundle) Negotiate(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b weighted) int { return cmp.Compare(b.q, a.q) })
+
+	for _, tag := range tags {
+		if _, ok := b.catalogs[tag.tag]; ok {
+			return tag.tag
+		}
+
+		base, _, _ := strings.Cut(tag.tag, "-")
+		if _, ok := b.catalogs[base]; ok {
+			return base
+		}
+	}
+
+	return DefaultLocale
+}
+
+type localizer struct {
+	locale   string
+	locales  []Locale
+	catalog  Catalog
+	fallback Catalog
+}
+
+func NewMiddleware(bundle *Bundle) func(http.Handler) http.Handler {
+	const cookieMaxAge = 365 * 24 * time.Hour
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			locale := ""
+			if tag := r.URL.Query().Get(queryParam); tag != "" {
+				if _, ok := bundle.catalogs[tag]; ok {
+					locale = tag
+					http.SetCookie(w, &http.Cookie{
+						Name:     cookieName,
+						Value:    tag,
+						Path:     "/",
+						MaxAge:   int(cookieMaxAge.Seconds()),
+						Secure:   proxy.IsHTTPS(r),
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			}
+			if cookie, err := r.Cookie(cookieName); locale == "" && err == nil {
+				if _, ok := bundle.catalogs[cookie.Value]; ok {
+					locale = cookie.Value
+				}
+			}
+			if locale == "" {
+				locale = bundle.Negotiate(r.Header.Get("Accept-Language"))
+			}
+
+			w.Header().Add("Vary", "Accept-Language, Cookie")
+			w.Header().Set("Content-Language", locale)
+
+			ctx := context.WithValue(r.Context(), localizerKey, localizer{
+				locale:   locale,
+				locales:  bundle.locales,
+				catalog:  bundle.catalogs[locale],
+				fallback: bundle.catalogs[DefaultLocale],
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func T(ctx context.Context, id string, params ...any) string {
+	l := fromContext(ctx)
+
+	format, ok := l.catalog[id]
+	if !ok {
+		format, ok = l.fallback[id]
+	}
+	if !ok {
+		return id
+	}
+
+	if len(params) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, params...)
+}
+
+func Translate(ctx context.Context, message Message) string {
+	return T(ctx, message.ID, message.Params...)
+}
+
+func CurrentLocale(ctx context.Context) string {
+	return fromContext(ctx).locale
+}
+
+func Locales(ctx context.Context) []Locale {
+	return fromContext(ctx).locales
+}
+
+func fromContext(ctx context.Context) localizer {
+	if l, ok := ctx.Value(localizerKey).(localizer); ok {
+		return l
+	}
+
+	bundle, err := loadDefault()
+	if err != nil {
+		return localizer{locale: DefaultLocale}
+	}
+
+	return localizer{locale: DefaultLocale, catalog: bundle.catalogs[DefaultLocale]}
+}
@@ -0,0 +1,191 @@
+package i18n
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogs(t *testing.T) {
+	bundle, err := LoadBundle()
+	require.NoError(t, err)
+
+	verbs := regexp.MustCompile(`%(\[\d+\])?[a-z]`)
+	reference, _ := bundle.Catalog(DefaultLocale)
+
+	for _, locale := range bundle.Locales() {
+		t.Run(locale.Tag, func(t *testing.T) {
+			catalog, ok := bundle.Catalog(locale.Tag)
+			require.True(t, ok)
+
+			for key, message := range reference {
+				translated, ok := catalog[key]
+				require.True(t, ok, "catalog %q is missing key %q", locale.Tag, key)
+				require.NotEmpty(t, translated, "catalog %q has empty key %q", locale.Tag, key)
+
+				expected := verbs.FindAllString(message, -1)
+				actual := verbs.FindAllString(translated, -1)
+				slices.Sort(expected)
+				slices.Sort(actual)
+				require.Equal(t, expected, actual, "catalog %q has mismatched parameters in %q", locale.Tag, key)
+			}
+
+			for key := range catalog {
+				_, ok := reference[key]
+				require.True(t, ok, "catalog %q has unknown key %q", locale.Tag, key)
+			}
+		})
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	t.Run("it requires default locale", func(t *testing.T) {
+		_, err := loadBundle(fstest.MapFS{"locales/ru.json": {Data: []byte(`{}`)}})
+
+		require.ErrorIs(t, err, errDefaultLocaleMissing)
+	})
+
+	t.Run("it reports invalid catalog", func(t *testing.T) {
+		_, err := loadBundle(fstest.MapFS{"locales/en.json": {Data: []byte(`{`)}})
+
+		require.Error(t, err)
+	})
+}
+
+func TestBundle_Negotiate(t *testing.T) {
+	bundle := &Bundle{catalogs: map[string]Catalog{"en": {}, "ru": {}}}
+
+	tests := map[string]string{
+		"":                            "en",
+		"ru":                          "ru",
+		"ru-RU,ru;q=0.9":              "ru",
+		"de-DE,de;q=0.9,ru;q=0.8":     "ru",
+		"en;q=0.5, ru;q=0.7":          "ru",
+		"ru;q=0, en":                  "en",
+		"fr, *;q=0.1":                 "en",
+		"ru;q=invalid, en-GB;q=0.8":   "en",
+		"EN-us":                       "en",
+		"de, ru-Cyrl-RU;q=0.3, fr-CA": "ru",
+	}
+
+	for header, expected := range tests {
+		t.Run(header, func(t *testing.T) {
+			require.Equal(t, expected, bundle.Negotiate(header))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	bundle, err := LoadBundle()
+	require.NoError(t, err)
+
+	serve := func(r *http.Request) (*httptest.ResponseRecorder, string) {
+		var title string
+		handler := NewMiddleware(bundle)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			title = T(r.Context(), "share.title")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w, title
+	}
+
+	t.Run("it negotiates accept language", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+
+		w, title := serve(r)
+
+		require.Equal(t, "Поделиться секретом", title)
+		require.Equal(t, "ru", w.Header().Get("Content-Language"))
+	})
+
+	t.Run("it prefers cookie over accept language", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", "ru")
+		r.AddCookie(&http.Cookie{Name: cookieName, Value: "en"})
+
+		_, title := serve(r)
+
+		require.Equal(t, "Share secret", title)
+	})
+
+	t.Run("it switches locale from query and remembers it", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?lang=ru", nil)
+		r.AddCookie(&http.Cookie{Name: cookieName, Value: "en"})
+
+		w, title := serve(r)
+
+		require.Equal(t, "Поделиться секретом", title)
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "ru", cookies[0].Value)
+		require.False(t, cookies[0].Secure)
+	})
+
+	t.Run("it marks locale cookie secure over https", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?lang=ru", nil)
+		r.TLS = &tls.ConnectionState{}
+
+		w, _ := serve(r)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.True(t, cookies[0].Secure)
+	})
+
+	t.Run("it ignores unknown locales", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?lang=xx", nil)
+		r.AddCookie(&http.Cookie{Name: cookieName, Value: "yy"})
+
+		w, title := serve(r)
+
+		require.Equal(t, "Share secret", title)
+		require.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestT(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it uses default locale without middleware", func(t *testing.T) {
+		require.Equal(t, "Share secret", T(ctx, "share.title"))
+		require.Equal(t, "en", CurrentLocale(ctx))
+	})
+
+	t.Run("it formats parameters", func(t *testing.T) {
+		message := Msg("violation.passphrase_too_long", 32)
+
+		require.Equal(t, "The passphrase must be less than or equal to 32 bytes", Translate(ctx, message))
+	})
+
+	t.Run("it falls back to default catalog and message id", func(t *testing.T) {
+		ctx := context.WithValue(ctx, localizerKey, localizer{
+			locale:   "ru",
+			catalog:  Catalog{},
+			fallback: Catalog{"share.title": "Share secret"},
+		})
+
+		require.Equal(t, "Share secret", T(ctx, "share.title"))
+		require.Equal(t, "missing.key", T(ctx, "missing.key"))
+	})
+
+	t.Run("it lists locales in order", func(t *testing.T) {
+		bundle, err := LoadBundle()
+		require.NoError(t, err)
+
+		tags := make([]string, 0)
+		for _, locale := range bundle.Locales() {
+			tags = append(tags, locale.Tag)
+		}
+		require.Equal(t, []string{"en", "ru"}, tags)
+	})
+}
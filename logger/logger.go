@@ -4,11 +4,13 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"slices"
 
 	"github.com/lmittmann/tint"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func New(out io.Writer, level slog.Level, tinted bool) *slog.Logger {
+func New(out io.Writer, level slog.Level, tinted bool, redaction Redaction) *slog.Logger {
 	var handler slog.Handler
 	if tinted {
 		handler = tint.NewHandler(out, &tint.Options{Level: level})
@@ -16,35 +18,121 @@ func New(out io.Writer, level slog.Level, tinted bool) *slog.Logger {
 		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})
 	}
 
-	return slog.New(&handlerWrapper{handler})
+	return slog.New(&handlerWrapper{Handler: handler, redaction: redaction})
 }
 
 type ctxKey string
 
-const requestIDKey ctxKey = "requestID"
+const (
+	requestIDKey ctxKey = "requestID"
+	attrsKey     ctxKey = "attrs"
+)
+
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, attrsKey, append(slices.Clip(AttrsFromContext(ctx)), attrs...))
+}
+
+func AttrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey).([]slog.Attr)
+
+	return attrs
+}
 
 type handlerWrapper struct {
 	slog.Handler
+	redaction Redaction
+	groups    []loggerGroup
+}
+
+// Groups opened by the logger are applied to the record attrs in Handle, so context attrs stay at the top level.
+type loggerGroup struct {
+	name  string
+	attrs []slog.Attr
 }
 
-const requestIDAttr string = "requestID"
+const (
+	requestIDAttr string = "requestID"
+	traceIDAttr   string = "traceID"
+	spanIDAttr    string = "spanID"
+)
 
 func (h *handlerWrapper) Handle(ctx context.Context, record slog.Record) error {
+	var attrs []slog.Attr
 	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
-		record.AddAttrs(slog.String(requestIDAttr, requestID))
+		attrs = append(attrs, slog.String(requestIDAttr, requestID))
+	}
+
+	attrs = append(attrs, AttrsFromContext(ctx)...)
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs,
+			slog.String(traceIDAttr, span.TraceID().String()),
+			slog.String(spanIDAttr, span.SpanID().String()),
+		)
+	}
+
+	if !h.redaction.empty() {
+		attrs = h.redaction.attrs(attrs)
+	}
+
+	if !h.redaction.empty() || len(h.groups) > 0 {
+		record = h.resolveRecord(record)
 	}
+	record.AddAttrs(attrs...)
 
 	return h.Handler.Handle(ctx, record) //nolint:wrapcheck
 }
 
+func (h *handlerWrapper) resolveRecord(record slog.Record) slog.Record {
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if !h.redaction.empty() {
+			attr = h.redaction.attr(attr)
+		}
+		attrs = append(attrs, attr)
+
+		return true
+	})
+
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := h.groups[i]
+		attrs = []slog.Attr{{Key: group.name, Value: slog.GroupValue(append(slices.Clip(group.attrs), attrs...)...)}}
+	}
+
+	resolved := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	resolved.AddAttrs(attrs...)
+
+	return resolved
+}
+
 func (h *handlerWrapper) WithAttrs(attrs []slog.Attr) slog.Handler {
-	handler := h.Handler.WithAttrs(attrs)
+	if len(attrs) == 0 {
+		return h
+	}
 
-	return &handlerWrapper{handler}
+	if !h.redaction.empty() {
+		attrs = h.redaction.attrs(attrs)
+	}
+
+	if len(h.groups) == 0 {
+		return &handlerWrapper{Handler: h.Handler.WithAttrs(attrs), redaction: h.redaction}
+	}
+
+	groups := slices.Clone(h.groups)
+	last := &groups[len(groups)-1]
+	last.attrs = append(slices.Clip(last.attrs), attrs...)
+
+	return &handlerWrapper{Handler: h.Handler, redaction: h.redaction, groups: groups}
 }
 
 func (h *handlerWrapper) WithGroup(name string) slog.Handler {
-	handler := h.Handler.WithGroup(name)
+	if name == "" {
+		return h
+	}
 
-	return &handlerWrapper{handler}
+	return &handlerWrapper{
+		Handler:   h.Handler,
+		redaction: h.redaction,
+		groups:    append(slices.Clip(h.groups), loggerGroup{name: name}),
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/lmittmann/tint"
 	"github.com/pugkong/sharesecrets/loggertest"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNew(t *testing.T) {
@@ -76,7 +77,7 @@ func TestNew(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			out := &bytes.Buffer{}
 
-			logger := New(out, test.level, test.tinted)
+			logger := New(out, test.level, test.tinted, Redaction{})
 
 			require.NotNil(t, logger)
 			require.IsType(t, &handlerWrapper{}, logger.Handler())
@@ -102,13 +103,37 @@ func TestNew(t *testing.T) {
 func TestHandlerWrapper(t *testing.T) {
 	t.Run("it passes std tests", func(t *testing.T) {
 		buf := &bytes.Buffer{}
-		handler := handlerWrapper{slog.NewJSONHandler(buf, nil)}
+		handler := handlerWrapper{Handler: slog.NewJSONHandler(buf, nil)}
 
 		err := slogtest.TestHandler(&handler, func() []map[string]any { return loggertest.ParseJSON(t, buf) })
 		require.NoError(t, err)
 	})
 
-	wrap := func(h slog.Handler) slog.Handler { return &handlerWrapper{h} }
+	t.Run("it passes std tests with context attrs", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		ctx := WithAttrs(context.Background(), slog.String("clientIP", "192.0.2.1"))
+		handler := contextHandler{Handler: &handlerWrapper{Handler: slog.NewJSONHandler(buf, nil)}, ctx: ctx}
+
+		err := slogtest.TestHandler(&handler, func() []map[string]any {
+			out := loggertest.ParseJSON(t, buf)
+			for _, m := range out {
+				require.Equal(t, "192.0.2.1", m["clientIP"])
+			}
+
+			return out
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("it passes std tests with redaction", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		handler := handlerWrapper{Handler: slog.NewJSONHandler(buf, nil), redaction: Redaction{Attrs: []string{"secret"}}}
+
+		err := slogtest.TestHandler(&handler, func() []map[string]any { return loggertest.ParseJSON(t, buf) })
+		require.NoError(t, err)
+	})
+
+	wrap := func(h slog.Handler) slog.Handler { return &handlerWrapper{Handler: h} }
 
 	t.Run("it adds requestID attr", func(t *testing.T) {
 		logger, output := loggertest.NewWithHandlerWrapper(wrap)
@@ -122,6 +147,75 @@ func TestHandlerWrapper(t *testing.T) {
 		require.Equal(t, requestID, out[0][requestIDAttr])
 	})
 
+	t.Run("it adds trace and span ID attrs", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		span := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+			SpanID:  trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), span)
+		logger.InfoContext(ctx, "test")
+
+		out := output(t)
+		require.Len(t, out, 1)
+		require.Equal(t, "0102030405060708090a0b0c0d0e0f10", out[0][traceIDAttr])
+		require.Equal(t, "0102030405060708", out[0][spanIDAttr])
+	})
+
+	t.Run("it adds context attrs", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		ctx := WithAttrs(context.Background(), slog.String("clientIP", "192.0.2.1"))
+		logger.InfoContext(ctx, "test", slog.Int("status", 200))
+
+		out := output(t)
+		require.Equal(t, []map[string]any{{"level": "INFO", "msg": "test", "status": float64(200), "clientIP": "192.0.2.1"}}, out)
+	})
+
+	t.Run("it keeps context attrs out of logger groups", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		ctx := WithAttrs(context.WithValue(context.Background(), requestIDKey, "42"), slog.String("clientIP", "192.0.2.1"))
+		logger.WithGroup("secret").With(slog.String("layer", "store")).InfoContext(ctx, "test", slog.Int("status", 200))
+
+		out := output(t)
+		require.Equal(
+			t,
+			[]map[string]any{{
+				"level":       "INFO",
+				"msg":         "test",
+				requestIDAttr: "42",
+				"clientIP":    "192.0.2.1",
+				"secret":      map[string]any{"layer": "store", "status": float64(200)},
+			}},
+			out,
+		)
+	})
+
+	t.Run("it redacts context attrs", func(t *testing.T) {
+		redaction := Redaction{Attrs: []string{"key"}}
+		logger, output := loggertest.NewWithHandlerWrapper(func(h slog.Handler) slog.Handler {
+			return &handlerWrapper{Handler: h, redaction: redaction}
+		})
+
+		ctx := WithAttrs(context.Background(), slog.String("key", "0123456789abcdef"))
+		logger.InfoContext(ctx, "test")
+
+		loggertest.RequireNotLogged(t, output(t), "0123456789abcdef")
+	})
+
+	t.Run("it skips trace attrs without span", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		logger.InfoContext(context.Background(), "test")
+
+		out := output(t)
+		require.Len(t, out, 1)
+		require.NotContains(t, out[0], traceIDAttr)
+		require.NotContains(t, out[0], spanIDAttr)
+	})
+
 	t.Run("it wraps Handler.WithAttrs method", func(t *testing.T) {
 		logger, output := loggertest.NewWithHandlerWrapper(wrap)
 
@@ -151,3 +245,46 @@ func TestHandlerWrapper(t *testing.T) {
 		require.IsType(t, &handlerWrapper{}, logger.Handler())
 	})
 }
+
+type contextHandler struct {
+	slog.Handler
+	ctx context.Context //nolint:containedctx
+}
+
+func (h *contextHandler) Handle(_ context.Context, record slog.Record) error {
+	return h.Handler.Handle(h.ctx, record) //nolint:wrapcheck
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
+
+func TestWithAttrs(t *testing.T) {
+	t.Run("it returns no attrs for empty context", func(t *testing.T) {
+		require.Empty(t, AttrsFromContext(context.Background()))
+	})
+
+	t.Run("it appends attrs without changing parent context", func(t *testing.T) {
+		parent := WithAttrs(context.Background(), slog.String("clientIP", "192.0.2.1"))
+		first := WithAttrs(parent, slog.String("user", "first"))
+		second := WithAttrs(parent, slog.String("user", "second"))
+
+		require.Equal(t, []slog.Attr{slog.String("clientIP", "192.0.2.1")}, AttrsFromContext(parent))
+		require.Equal(t, []slog.Attr{slog.String("clientIP", "192.0.2.1"), slog.String("user", "first")}, AttrsFromContext(first))
+		require.Equal(t, []slog.Attr{slog.String("clientIP", "192.0.2.1"), slog.String("user", "second")}, AttrsFromContext(second))
+	})
+
+	t.Run("it works with tinted logger", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		logger := slog.New(&handlerWrapper{Handler: tint.NewHandler(out, &tint.Options{NoColor: true})})
+
+		ctx := WithAttrs(context.Background(), slog.String("clientIP", "192.0.2.1"))
+		logger.InfoContext(ctx, "test")
+
+		require.Contains(t, out.String(), "INF test clientIP=192.0.2.1")
+	})
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+type Redaction struct {
+	Attrs   []string
+	Paths   []*regexp.Regexp
+	Replace func(string) string
+}
+
+func (r Redaction) empty() bool {
+	return len(r.Attrs) == 0 && len(r.Paths) == 0
+}
+
+func (r Redaction) attrs(attrs []slog.Attr) []slog.Attr {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = r.attr(attr)
+	}
+
+	return redactedAttrs
+}
+
+func (r Redaction) attr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	switch {
+	case slices.Contains(r.Attrs, attr.Key):
+		return slog.String(attr.Key, r.replace(attr.Value.String()))
+	case attr.Value.Kind() == slog.KindGroup:
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(r.attrs(attr.Value.Group())...)}
+	case attr.Value.Kind() == slog.KindString:
+		return slog.String(attr.Key, r.path(attr.Value.String()))
+	default:
+		return attr
+	}
+}
+
+func (r Redaction) path(value string) string {
+	for _, pattern := range r.Paths {
+		match := pattern.FindStringSubmatchIndex(value)
+		if match == nil {
+			continue
+		}
+
+		groups := match[2:]
+		if len(groups) == 0 {
+			groups = match[:2]
+		}
+
+		var b strings.Builder
+		last := 0
+		for i := 0; i < len(groups); i += 2 {
+			start, end := groups[i], groups[i+1]
+			if start < last {
+				continue
+			}
+			b.WriteString(value[last:start])
+			b.WriteString(r.replace(value[start:end]))
+			last = end
+		}
+		b.WriteString(value[last:])
+		value = b.String()
+	}
+
+	return value
+}
+
+func (r Redaction) replace(value string) string {
+	if r.Replace == nil {
+		return redacted
+	}
+
+	return r.Replace(value)
+}
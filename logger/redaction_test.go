@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedaction(t *testing.T) {
+	redaction := Redaction{
+		Attrs: []string{"key"},
+		Paths: []*regexp.Regexp{
+			regexp.MustCompile(`^/([0-9a-f]{4})$`),
+			regexp.MustCompile(`^/api/([0-9a-f]{4})/open$`),
+		},
+		Replace: strings.ToUpper,
+	}
+
+	tests := map[string]struct {
+		redaction Redaction
+		attr      slog.Attr
+		expected  slog.Attr
+	}{
+		"it replaces redacted attr": {
+			redaction: redaction,
+			attr:      slog.String("key", "abcd"),
+			expected:  slog.String("key", "ABCD"),
+		},
+		"it replaces non-string redacted attr": {
+			redaction: redaction,
+			attr:      slog.Int("key", 42),
+			expected:  slog.String("key", "42"),
+		},
+		"it replaces redacted attr in group": {
+			redaction: redaction,
+			attr:      slog.Group("request", slog.String("key", "abcd"), slog.Int("status", 200)),
+			expected:  slog.Group("request", slog.String("key", "ABCD"), slog.Int("status", 200)),
+		},
+		"it replaces path pattern group": {
+			redaction: redaction,
+			attr:      slog.String("path", "/api/abcd/open"),
+			expected:  slog.String("path", "/api/ABCD/open"),
+		},
+		"it keeps unmatched path": {
+			redaction: redaction,
+			attr:      slog.String("path", "/style.css"),
+			expected:  slog.String("path", "/style.css"),
+		},
+		"it replaces whole match without groups": {
+			redaction: Redaction{Paths: []*regexp.Regexp{regexp.MustCompile(`token=\w+`)}},
+			attr:      slog.String("query", "a=1&token=abc&b=2"),
+			expected:  slog.String("query", "a=1&"+redacted+"&b=2"),
+		},
+		"it uses placeholder without replace func": {
+			redaction: Redaction{Attrs: []string{"key"}},
+			attr:      slog.String("key", "abcd"),
+			expected:  slog.String("key", redacted),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := test.redaction.attr(test.attr)
+
+			require.True(t, test.expected.Equal(actual), "expected %s, got %s", test.expected, actual)
+		})
+	}
+}
+
+func TestHandlerWrapper_Redaction(t *testing.T) {
+	const key = "0123456789abcdef"
+
+	redaction := Redaction{
+		Attrs: []string{"key"},
+		Paths: []*regexp.Regexp{regexp.MustCompile(`^/([0-9a-f]+)$`)},
+	}
+	wrap := func(h slog.Handler) slog.Handler { return &handlerWrapper{Handler: h, redaction: redaction} }
+
+	t.Run("it redacts record attrs", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		logger.LogAttrs(context.Background(), slog.LevelInfo, "test", slog.String("key", key), slog.String("path", "/"+key))
+
+		out := output(t)
+		loggertest.RequireNotLogged(t, out, key)
+		require.Equal(t, []map[string]any{{"level": "INFO", "msg": "test", "key": redacted, "path": "/" + redacted}}, out)
+	})
+
+	t.Run("it redacts attrs added with logger.With", func(t *testing.T) {
+		logger, output := loggertest.NewWithHandlerWrapper(wrap)
+
+		logger.WithGroup("secret").With(slog.String("key", key)).Info("test")
+
+		out := output(t)
+		loggertest.RequireNotLogged(t, out, key)
+		require.Equal(t, map[string]any{"key": redacted}, out[0]["secret"])
+	})
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 )
@@ -21,6 +22,7 @@ func NewRequestLoggerMiddleware(logger *slog.Logger) *RequestLoggerMiddleware {
 func (m *RequestLoggerMiddleware) Handler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ww := newCustomResponseWriter(w)
+		r = r.WithContext(WithAttrs(r.Context(), slog.String("clientIP", clientIP(r))))
 
 		m.logger.LogAttrs(r.Context(), slog.LevelInfo, "HTTP request accepted",
 			slog.String("method", r.Method),
@@ -45,6 +47,15 @@ func (m *RequestLoggerMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
 type customResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,8 +12,10 @@ import (
 )
 
 func TestRequestLoggerMiddleware(t *testing.T) {
+	wrapHandler := func(h slog.Handler) slog.Handler { return &handlerWrapper{Handler: h} }
+
 	t.Run("it logs success requests", func(t *testing.T) {
-		logger, output := loggertest.New()
+		logger, output := loggertest.NewWithHandlerWrapper(wrapHandler)
 		middleware := NewRequestLoggerMiddleware(logger)
 		middleware.now = func() time.Time { return time.Time{} }
 		handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
@@ -24,15 +27,15 @@ func TestRequestLoggerMiddleware(t *testing.T) {
 		require.Equal(
 			t,
 			[]map[string]any{
-				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/"},
-				{"level": "INFO", "msg": "HTTP request handled", "status": float64(200), "duration": "0s"},
+				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/", "clientIP": "192.0.2.1"},
+				{"level": "INFO", "msg": "HTTP request handled", "clientIP": "192.0.2.1", "status": float64(200), "duration": "0s"},
 			},
 			output(t),
 		)
 	})
 
 	t.Run("it logs failure requests", func(t *testing.T) {
-		logger, output := loggertest.New()
+		logger, output := loggertest.NewWithHandlerWrapper(wrapHandler)
 		middleware := NewRequestLoggerMiddleware(logger)
 		middleware.now = func() time.Time { return time.Time{} }
 		handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -46,15 +49,15 @@ func TestRequestLoggerMiddleware(t *testing.T) {
 		require.Equal(
 			t,
 			[]map[string]any{
-				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/"},
-				{"level": "ERROR", "msg": "HTTP request handled", "status": float64(500), "duration": "0s"},
+				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/", "clientIP": "192.0.2.1"},
+				{"level": "ERROR", "msg": "HTTP request handled", "clientIP": "192.0.2.1", "status": float64(500), "duration": "0s"},
 			},
 			output(t),
 		)
 	})
 
 	t.Run("it measures time", func(t *testing.T) {
-		logger, output := loggertest.New()
+		logger, output := loggertest.NewWithHandlerWrapper(wrapHandler)
 		middleware := NewRequestLoggerMiddleware(logger)
 		now := time.Now()
 		middleware.now = func() time.Time {
@@ -71,8 +74,8 @@ func TestRequestLoggerMiddleware(t *testing.T) {
 		require.Equal(
 			t,
 			[]map[string]any{
-				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/"},
-				{"level": "INFO", "msg": "HTTP request handled", "status": float64(200), "duration": "10ms"},
+				{"level": "INFO", "method": "GET", "msg": "HTTP request accepted", "path": "/", "clientIP": "192.0.2.1"},
+				{"level": "INFO", "msg": "HTTP request handled", "clientIP": "192.0.2.1", "status": float64(200), "duration": "10ms"},
 			},
 			output(t),
 		)
@@ -3,7 +3,10 @@ package loggertest
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 )
 
 type OutputFunc func(t TestingT) []map[string]any
@@ -13,7 +16,7 @@ func New() (*slog.Logger, OutputFunc) {
 }
 
 func NewWithHandlerWrapper(wrap func(slog.Handler) slog.Handler) (*slog.Logger, OutputFunc) {
-	buf := &bytes.Buffer{}
+	buf := &lockedBuffer{}
 	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
@@ -26,11 +29,28 @@ func NewWithHandlerWrapper(wrap func(slog.Handler) slog.Handler) (*slog.Logger,
 	})
 	logger := slog.New(wrap(handler))
 
-	output := func(t TestingT) []map[string]any { return ParseJSON(t, buf) }
+	output := func(t TestingT) []map[string]any {
+		buf.lock.Lock()
+		defer buf.lock.Unlock()
+
+		return ParseJSON(t, &buf.buf)
+	}
 
 	return logger, output
 }
 
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buf.Write(p) //nolint:wrapcheck
+}
+
 type TestingT interface {
 	Helper()
 	Fatal(args ...any)
@@ -54,3 +74,20 @@ func ParseJSON(t TestingT, buf *bytes.Buffer) []map[string]any {
 
 	return ms
 }
+
+func RequireNotLogged(t TestingT, logs []map[string]any, values ...string) {
+	t.Helper()
+
+	for _, log := range logs {
+		line, err := json.Marshal(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, value := range values {
+			if strings.Contains(string(line), value) {
+				t.Fatal(fmt.Sprintf("value %q is logged in %s", value, line))
+			}
+		}
+	}
+}
@@ -71,3 +71,25 @@ func (t *testingTSpy) Helper() {
 func (t *testingTSpy) Fatal(args ...any) {
 	t.fatalArgs = fmt.Sprintf("%+v", args)
 }
+
+func TestRequireNotLogged(t *testing.T) {
+	logger, output := New()
+	logger.Info("secret saved", slog.Group("request", slog.String("key", "0123abcd")))
+
+	t.Run("it passes when values are absent", func(t *testing.T) {
+		spy := &testingTSpy{}
+		RequireNotLogged(spy, output(t), "deadbeef")
+		require.True(t, spy.helperCalled)
+		require.Empty(t, spy.fatalArgs)
+	})
+
+	t.Run("it fails when a value is logged", func(t *testing.T) {
+		spy := &testingTSpy{}
+		RequireNotLogged(spy, output(t), "deadbeef", "0123abcd")
+		require.Equal(
+			t,
+			`[value "0123abcd" is logged in {"level":"INFO","msg":"secret saved","request":{"key":"0123abcd"}}]`,
+			spy.fatalArgs,
+		)
+	})
+}
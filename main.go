@@ -2,26 +2,22 @@ package main
 
 import (
 	"context"
-	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/pugkong/sharesecrets/app"
+	"github.com/pugkong/sharesecrets/cli"
 )
 
 func main() {
-	os.Exit(run(context.Background(), os.Getenv))
+	os.Exit(run(context.Background(), os.Args[1:], os.Getenv))
 }
 
-func run(ctx context.Context, getenv func(string) string) int {
+func run(ctx context.Context, args []string, getenv func(string) string) int {
 	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	app := app.New(getenv)
-	if err := app.Run(ctx); !errors.Is(err, context.Canceled) {
-		return 1
-	}
+	stdio := cli.IO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
 
-	return 0
+	return cli.Run(ctx, args, stdio, getenv)
 }
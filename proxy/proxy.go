@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+type Config struct {
+	PublicURL      *url.URL
+	TrustedProxies []netip.Prefix
+}
+
+type ctxKey string
+
+const baseURLKey ctxKey = "baseURL"
+
+func NewMiddleware(config Config) func(http.Handler) http.Handler {
+	var publicURL string
+	if config.PublicURL != nil {
+		publicURL = strings.TrimSuffix(config.PublicURL.String(), "/")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote := remoteAddr(r)
+			trusted := isTrusted(config.TrustedProxies, remote)
+
+			baseURL := publicURL
+			if baseURL == "" {
+				baseURL = requestBaseURL(r, trusted)
+			}
+
+			req := r.WithContext(context.WithValue(r.Context(), baseURLKey, baseURL))
+			if trusted {
+				if client, ok := forwardedFor(config.TrustedProxies, r.Header.Values("X-Forwarded-For")); ok {
+					remote = client
+				}
+			}
+			if remote.IsValid() {
+				req.RemoteAddr = remote.String()
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func BaseURL(r *http.Request) string {
+	if baseURL, ok := r.Context().Value(baseURLKey).(string); ok {
+		return baseURL
+	}
+
+	return requestBaseURL(r, false)
+}
+
+func IsHTTPS(r *http.Request) bool {
+	return strings.HasPrefix(BaseURL(r), "https://")
+}
+
+func ParsePublicURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, false
+	}
+
+	return u, true
+}
+
+func ParsePrefix(raw string) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(raw); err == nil {
+		return prefix.Masked(), true
+	}
+
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+func requestBaseURL(r *http.Request, trusted bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if trusted {
+		if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := firstValue(r.Header.Get("X-Forwarded-Host")); forwarded != "" {
+			host = forwarded
+		}
+	}
+
+	return scheme + "://" + host
+}
+
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap()
+}
+
+func forwardedFor(trusted []netip.Prefix, values []string) (netip.Addr, bool) {
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !isTrusted(trusted, client) {
+			break
+		}
+	}
+
+	return client, client.IsValid()
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	publicURL, ok := ParsePublicURL("https://secrets.example.com/")
+	require.True(t, ok)
+
+	tests := map[string]struct {
+		config     Config
+		remoteAddr string
+		headers    map[string]string
+		baseURL    string
+		https      bool
+		clientIP   string
+	}{
+		"direct request": {
+			config:     Config{TrustedProxies: trusted},
+			remoteAddr: "192.0.2.1:1234",
+			baseURL:    "http://example.com",
+			clientIP:   "192.0.2.1",
+		},
+		"untrusted proxy headers": {
+			config:     Config{TrustedProxies: trusted},
+			remoteAddr: "192.0.2.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.1",
+				"X-Forwarded-Host":  "evil.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			baseURL:  "http://example.com",
+			clientIP: "192.0.2.1",
+		},
+		"trusted proxy headers": {
+			config:     Config{TrustedProxies: trusted},
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.1, 10.0.0.2",
+				"X-Forwarded-Host":  "secrets.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			baseURL:  "https://secrets.example.com",
+			https:    true,
+			clientIP: "198.51.100.1",
+		},
+		"spoofed forwarded for": {
+			config:     Config{TrustedProxies: trusted},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 203.0.113.1"},
+			baseURL:    "http://example.com",
+			clientIP:   "203.0.113.1",
+		},
+		"public url": {
+			config:     Config{PublicURL: publicURL, TrustedProxies: trusted},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-Host": "evil.example.com"},
+			baseURL:    "https://secrets.example.com",
+			https:      true,
+			clientIP:   "10.0.0.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var baseURL, remoteAddr string
+			var https bool
+			handler := NewMiddleware(test.config)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				baseURL = BaseURL(r)
+				https = IsHTTPS(r)
+				remoteAddr = r.RemoteAddr
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for key, value := range test.headers {
+				r.Header.Set(key, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			require.Equal(t, test.baseURL, baseURL)
+			require.Equal(t, test.https, https)
+			require.Equal(t, test.clientIP, remoteAddr)
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	t.Run("it falls back to request host", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+
+		require.Equal(t, "https://example.com", BaseURL(r))
+	})
+}
+
+func TestParsePublicURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com":        true,
+		"http://example.com/secrets": true,
+		"example.com":                false,
+		"ftp://example.com":          false,
+		"https://example.com/?x=1":   false,
+		"":                           false,
+	}
+
+	for raw, valid := range tests {
+		t.Run(raw, func(t *testing.T) {
+			_, ok := ParsePublicURL(raw)
+
+			require.Equal(t, valid, ok)
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := map[string]struct {
+		prefix netip.Prefix
+		valid  bool
+	}{
+		"10.1.2.3/8":  {prefix: netip.MustParsePrefix("10.0.0.0/8"), valid: true},
+		"192.0.2.1":   {prefix: netip.MustParsePrefix("192.0.2.1/32"), valid: true},
+		"::1":         {prefix: netip.MustParsePrefix("::1/128"), valid: true},
+		"not-an-addr": {valid: false},
+	}
+
+	for raw, test := range tests {
+		t.Run(raw, func(t *testing.T) {
+			prefix, ok := ParsePrefix(raw)
+
+			require.Equal(t, test.valid, ok)
+			require.Equal(t, test.prefix, prefix)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package secret
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pugkong/sharesecrets/api"
+	"github.com/pugkong/sharesecrets/i18n"
+	"github.com/pugkong/sharesecrets/proxy"
+)
+
+type APIHandler struct {
+	secrets   *Service
+	responder *api.Responder
+}
+
+func NewAPIHandler(secrets *Service, responder *api.Responder) *APIHandler {
+	return &APIHandler{
+		secrets:   secrets,
+		responder: responder,
+	}
+}
+
+type APIShareRequest struct {
+	Message    string         `json:"message"`
+	Passphrase string         `json:"passphrase"`
+	Expire     APIShareExpire `json:"expire"`
+}
+
+type APIShareExpire struct {
+	Amount *int   `json:"amount"`
+	Unit   string `json:"unit"`
+}
+
+type APIShareResponse struct {
+	Key      string    `json:"key"`
+	URL      string    `json:"url"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+type APIOpenRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type APIOpenResponse struct {
+	Message string `json:"message"`
+}
+
+func (h *APIHandler) Share(w http.ResponseWriter, r *http.Request) {
+	const attempts = 3
+
+	var request APIShareRequest
+	if err := h.responder.Decode(w, r, &request); err != nil {
+		h.responder.Error(r.Context(), w, http.StatusBadRequest, api.ErrorBody{Code: api.CodeInvalidRequest, Message: err.Error()})
+
+		return
+	}
+
+	amount := 15
+	if request.Expire.Amount != nil {
+		amount = *request.Expire.Amount
+	}
+
+	data := createData{
+		Passphrase: request.Passphrase,
+		Message:    request.Message,
+		Expire: createExpireData{
+			Amount: strconv.Itoa(amount),
+			Unit:   cmp.Or(request.Expire.Unit, "minutes"),
+		},
+	}
+
+	if violations := validateShareData(data); len(violations) > 0 {
+		messages := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			messages = append(messages, i18n.Translate(r.Context(), violation))
+		}
+
+		h.responder.Error(r.Context(), w, http.StatusUnprocessableEntity, api.ErrorBody{
+			Code:       api.CodeValidationFailed,
+			Message:    "Request validation failed",
+			Violations: messages,
+		})
+
+		return
+	}
+
+	expireAt := time.Now().Add(data.Expire.Duration())
+	message := []byte(data.Message)
+	key, err := h.secrets.Store(r.Context(), StoreRequest{
+		Passphrase: data.Passphrase,
+		Message:    message,
+		Attempts:   attempts,
+		ExpireAt:   expireAt,
+	})
+	clear(message)
+	if errors.Is(err, ErrStoreFull) {
+		h.responder.Error(r.Context(), w, http.StatusServiceUnavailable, api.ErrorBody{
+			Code:    api.CodeStoreFull,
+			Message: "Service is at capacity, try again later",
+		})
+
+		return
+	}
+	if err != nil {
+		h.responder.ServerError(r.Context(), w, err)
+
+		return
+	}
+
+	h.responder.JSON(r.Context(), w, http.StatusCreated, APIShareResponse{
+		Key:      key,
+		URL:      fmt.Sprintf("%s/%s", proxy.BaseURL(r), key),
+		ExpireAt: expireAt.UTC().Truncate(time.Second),
+	})
+}
+
+func (h *APIHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var request APIOpenRequest
+	if err := h.responder.Decode(w, r, &request); err != nil {
+		h.responder.Error(r.Context(), w, http.StatusBadRequest, api.ErrorBody{Code: api.CodeInvalidRequest, Message: err.Error()})
+
+		return
+	}
+
+	message, err := h.secrets.Retrieve(r.Context(), RetrieveRequest{
+		Key:        r.PathValue("key"),
+		Passphrase: request.Passphrase,
+	})
+	switch {
+	case err == nil:
+		h.responder.SensitiveJSON(r.Context(), w, http.StatusOK, "message", message)
+		clear(message)
+	case errors.Is(err, ErrNotFound):
+		h.responder.Error(r.Context(), w, http.StatusNotFound, api.ErrorBody{Code: api.CodeNotFound, Message: "Secret not found"})
+	case errors.Is(err, ErrExpired):
+		h.responder.Error(r.Context(), w, http.StatusGone, api.ErrorBody{Code: api.CodeExpired, Message: "Secret expired"})
+	case errors.Is(err, ErrInvalidPassphrase):
+		h.responder.Error(r.Context(), w, http.StatusForbidden, api.ErrorBody{
+			Code:    api.CodeInvalidPassphrase,
+			Message: "Invalid passphrase",
+		})
+	default:
+		h.responder.ServerError(r.Context(), w, err)
+	}
+}
@@ -0,0 +1,157 @@
+package secret
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newLimitedHandler := func(limits InMemoryLimits) http.Handler {
+		service := NewService(
+			logger,
+			NewSecretboxEncryptor(logger),
+			NewInMemoryStore(logger, limits),
+			CleanupSchedule{Interval: time.Minute},
+			time.Now,
+		)
+		handler := NewAPIHandler(service, api.NewResponder(logger))
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/v1/secrets", handler.Share)
+		mux.HandleFunc("POST /api/v1/secrets/{key}/open", handler.Open)
+
+		return mux
+	}
+
+	newHandler := func() http.Handler {
+		return newLimitedHandler(InMemoryLimits{})
+	}
+
+	post := func(handler http.Handler, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	share := func(t *testing.T, handler http.Handler, body string) APIShareResponse {
+		t.Helper()
+
+		w := post(handler, "/api/v1/secrets", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response APIShareResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		return response
+	}
+
+	t.Run("it shares and opens secret", func(t *testing.T) {
+		handler := newHandler()
+
+		shared := share(t, handler, `{"message": "Message", "passphrase": "Passphrase", "expire": {"amount": 1, "unit": "hours"}}`)
+		require.Len(t, shared.Key, 32)
+		require.Equal(t, "http://example.com/"+shared.Key, shared.URL)
+		require.WithinDuration(t, time.Now().Add(time.Hour), shared.ExpireAt, 2*time.Second)
+
+		w := post(handler, "/api/v1/secrets/"+shared.Key+"/open", `{"passphrase": "Passphrase"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"message": "Message"}`, w.Body.String())
+
+		w = post(handler, "/api/v1/secrets/"+shared.Key+"/open", `{"passphrase": "Passphrase"}`)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.JSONEq(t, `{"error": {"code": "not_found", "message": "Secret not found"}}`, w.Body.String())
+	})
+
+	t.Run("it uses default expiration", func(t *testing.T) {
+		shared := share(t, newHandler(), `{"message": "Message"}`)
+
+		require.WithinDuration(t, time.Now().Add(15*time.Minute), shared.ExpireAt, 2*time.Second)
+	})
+
+	t.Run("it rejects non-positive expiration", func(t *testing.T) {
+		tests := map[string]string{
+			"zero":     `{"message": "Message", "expire": {"amount": 0, "unit": "hours"}}`,
+			"negative": `{"message": "Message", "expire": {"amount": -1}}`,
+		}
+
+		for name, body := range tests {
+			t.Run(name, func(t *testing.T) {
+				w := post(newHandler(), "/api/v1/secrets", body)
+
+				require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				require.JSONEq(
+					t,
+					`{"error": {
+						"code": "validation_failed",
+						"message": "Request validation failed",
+						"violations": ["The expire field must be positive"]
+					}}`,
+					w.Body.String(),
+				)
+			})
+		}
+	})
+
+	t.Run("it reports invalid passphrase", func(t *testing.T) {
+		handler := newHandler()
+		shared := share(t, handler, `{"message": "Message", "passphrase": "Passphrase"}`)
+
+		w := post(handler, "/api/v1/secrets/"+shared.Key+"/open", `{"passphrase": "Invalid"}`)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.JSONEq(t, `{"error": {"code": "invalid_passphrase", "message": "Invalid passphrase"}}`, w.Body.String())
+	})
+
+	t.Run("it reports validation violations", func(t *testing.T) {
+		w := post(newHandler(), "/api/v1/secrets", `{"message": "Message", "expire": {"amount": 2, "unit": "hours"}, "passphrase": "`+
+			strings.Repeat("a", 33)+`"}`)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.JSONEq(
+			t,
+			`{"error": {
+				"code": "validation_failed",
+				"message": "Request validation failed",
+				"violations": ["The passphrase must be less than or equal to 32 bytes"]
+			}}`,
+			w.Body.String(),
+		)
+	})
+
+	t.Run("it reports malformed requests", func(t *testing.T) {
+		handler := newHandler()
+
+		w := post(handler, "/api/v1/secrets", `{"message": `)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), api.CodeInvalidRequest)
+
+		w = post(handler, "/api/v1/secrets/key/open", `[]`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), api.CodeInvalidRequest)
+	})
+
+	t.Run("it reports store at capacity", func(t *testing.T) {
+		handler := newLimitedHandler(InMemoryLimits{MaxSecrets: 1})
+		share(t, handler, `{"message": "Message"}`)
+
+		w := post(handler, "/api/v1/secrets", `{"message": "Message"}`)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.JSONEq(
+			t,
+			`{"error": {"code": "store_full", "message": "Service is at capacity, try again later"}}`,
+			w.Body.String(),
+		)
+	})
+}
@@ -0,0 +1,284 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pugkong/sharesecrets/tracing"
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ Store = &BoltStore{}
+
+var (
+	boltSecretsBucket = []byte("secrets")
+	boltExpiryBucket  = []byte("expiry")
+
+	errCorruptedSecret = errors.New("corrupted secret")
+)
+
+const (
+	boltDirMode  os.FileMode = 0o700
+	boltFileMode os.FileMode = 0o600
+
+	boltHeaderSize = 16
+)
+
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func OpenBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), boltDirMode); err != nil {
+		return nil, fmt.Errorf("create db directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, boltFileMode, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+
+	if err := os.Chmod(path, boltFileMode); err != nil {
+		return nil, errors.Join(fmt.Errorf("restrict db permissions: %w", err), db.Close())
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltSecretsBucket, boltExpiryBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("create %s bucket: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("close db: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BoltStore) Load(ctx context.Context, key string) (Secret, error) {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Load")
+	defer span.End()
+
+	var secret Secret
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(boltSecretsBucket).Get([]byte(key))
+		if value == nil {
+			return ErrNotFound
+		}
+
+		var err error
+		secret, err = decodeBoltSecret(value)
+
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return secret, ErrNotFound
+	}
+	if err != nil {
+		return secret, tracing.Fail(span, fmt.Errorf("load secret: %w", err))
+	}
+
+	return secret, nil
+}
+
+func (s *BoltStore) Save(ctx context.Context, key string, secret Secret) error {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Save")
+	defer span.End()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := s.remove(tx, []byte(key)); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(boltSecretsBucket).Put([]byte(key), encodeBoltSecret(secret)); err != nil {
+			return fmt.Errorf("put secret: %w", err)
+		}
+
+		if err := tx.Bucket(boltExpiryBucket).Put(boltExpiryKey(secret.exp, []byte(key)), nil); err != nil {
+			return fmt.Errorf("put expiry index: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("save secret: %w", err))
+	}
+
+	return nil
+}
+
+func (s *BoltStore) Remove(ctx context.Context, key string) error {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Remove")
+	defer span.End()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return s.remove(tx, []byte(key))
+	})
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("remove secret: %w", err))
+	}
+
+	return nil
+}
+
+func (s *BoltStore) Cleanup(ctx context.Context) (int, error) {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Cleanup")
+	defer span.End()
+
+	var removed int
+	now := time.Now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		secrets := tx.Bucket(boltSecretsBucket)
+		cursor := tx.Bucket(boltExpiryBucket).Cursor()
+
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.First() {
+			exp, key := splitBoltExpiryKey(k)
+			if !now.After(exp) {
+				break
+			}
+
+			if err := secrets.Delete(key); err != nil {
+				return fmt.Errorf("delete secret: %w", err)
+			}
+			if err := cursor.Delete(); err != nil {
+				return fmt.Errorf("delete expiry index: %w", err)
+			}
+			removed++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("cleanup: %w", err))
+	}
+
+	return removed, nil
+}
+
+func (s *BoltStore) Stats(ctx context.Context) (Stats, error) {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Stats")
+	defer span.End()
+
+	var stats Stats
+	now := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSecretsBucket).ForEach(func(_, value []byte) error {
+			secret, err := decodeBoltSecret(value)
+			if err != nil {
+				return err
+			}
+
+			if now.After(secret.exp) {
+				stats.Expired++
+			} else {
+				stats.Active++
+			}
+			stats.Bytes += int64(len(secret.data))
+
+			return nil
+		})
+	})
+	if err != nil {
+		return stats, tracing.Fail(span, fmt.Errorf("stats: %w", err))
+	}
+
+	return stats, nil
+}
+
+func (s *BoltStore) Purge(ctx context.Context) (int, error) {
+	_, span := tracing.Start(ctx, tracerName, "BoltStore.Purge")
+	defer span.End()
+
+	var count int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		count = tx.Bucket(boltSecretsBucket).Stats().KeyN
+
+		for _, name := range [][]byte{boltSecretsBucket, boltExpiryBucket} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return fmt.Errorf("delete %s bucket: %w", name, err)
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return fmt.Errorf("create %s bucket: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("purge: %w", err))
+	}
+
+	return count, nil
+}
+
+func (s *BoltStore) remove(tx *bolt.Tx, key []byte) error {
+	secrets := tx.Bucket(boltSecretsBucket)
+
+	value := secrets.Get(key)
+	if value == nil {
+		return nil
+	}
+
+	secret, err := decodeBoltSecret(value)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Bucket(boltExpiryBucket).Delete(boltExpiryKey(secret.exp, key)); err != nil {
+		return fmt.Errorf("delete expiry index: %w", err)
+	}
+
+	if err := secrets.Delete(key); err != nil {
+		return fmt.Errorf("delete secret: %w", err)
+	}
+
+	return nil
+}
+
+func encodeBoltSecret(secret Secret) []byte {
+	value := make([]byte, boltHeaderSize, boltHeaderSize+len(secret.data))
+	binary.BigEndian.PutUint64(value[:8], uint64(secret.attempts))
+	binary.BigEndian.PutUint64(value[8:16], uint64(secret.exp.UnixNano()))
+
+	return append(value, secret.data...)
+}
+
+func decodeBoltSecret(value []byte) (Secret, error) {
+	if len(value) < boltHeaderSize {
+		return Secret{}, errCorruptedSecret
+	}
+
+	return Secret{
+		attempts: int(binary.BigEndian.Uint64(value[:8])),
+		exp:      time.Unix(0, int64(binary.BigEndian.Uint64(value[8:16]))),
+		data:     bytes.Clone(value[boltHeaderSize:]),
+	}, nil
+}
+
+func boltExpiryKey(exp time.Time, key []byte) []byte {
+	index := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(index, uint64(exp.UnixNano()))
+
+	return append(index, key...)
+}
+
+func splitBoltExpiryKey(index []byte) (time.Time, []byte) {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(index[:8]))), index[8:]
+}
@@ -0,0 +1,106 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltStore(t *testing.T) {
+	ctx := context.Background()
+
+	newStore := func(t *testing.T) (*BoltStore, string) {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "data", "secrets.db")
+		store, err := OpenBoltStore(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.Close() })
+
+		return store, path
+	}
+
+	t.Run("it removes expired items", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		err := store.Save(ctx, "expired", Secret{exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "active", Secret{exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+
+		// overwriting must move the secret in the expiry index
+		err = store.Save(ctx, "active", Secret{exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		removed, err := store.Cleanup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+
+		_, err = store.Load(ctx, "expired")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.Load(ctx, "active")
+		require.NoError(t, err)
+	})
+
+	t.Run("it reports stats and purges items", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		err := store.Save(ctx, "expired", Secret{data: []byte("12345"), exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "active", Secret{data: []byte("123"), exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		stats, err := store.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, Stats{Active: 1, Expired: 1, Bytes: 8}, stats)
+
+		count, err := store.Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		stats, err = store.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, Stats{}, stats)
+	})
+
+	t.Run("it persists items across reopening", func(t *testing.T) {
+		store, path := newStore(t)
+
+		err := store.Save(ctx, "key", Secret{data: []byte("data"), attempts: 1, exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+
+		store, err = OpenBoltStore(path)
+		require.NoError(t, err)
+		defer store.Close()
+
+		secret, err := store.Load(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("data"), secret.data)
+	})
+
+	t.Run("it restricts file permissions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.db")
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+		store, err := OpenBoltStore(path)
+		require.NoError(t, err)
+		defer store.Close()
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, boltFileMode, info.Mode().Perm())
+
+		_, path = newStore(t)
+		info, err = os.Stat(filepath.Dir(path))
+		require.NoError(t, err)
+		require.Equal(t, boltDirMode, info.Mode().Perm())
+	})
+}
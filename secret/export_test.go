@@ -0,0 +1,3 @@
+package secret
+
+var StartPostgres = startPostgres
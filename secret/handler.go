@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/pugkong/sharesecrets/html"
+	"github.com/pugkong/sharesecrets/i18n"
+	"github.com/pugkong/sharesecrets/proxy"
 )
 
 type Handler struct {
@@ -35,7 +37,7 @@ func (h *Handler) Share(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodPost {
-		data.Violations = h.validateShareData(data)
+		data.Violations = validateShareData(data)
 		if len(data.Violations) > 0 {
 			h.renderer.Component(r.Context(), w, http.StatusOK, createPage(data))
 
@@ -44,21 +46,29 @@ func (h *Handler) Share(w http.ResponseWriter, r *http.Request) {
 
 		request := StoreRequest{
 			Passphrase: data.Passphrase,
-			Message:    data.Message,
+			Message:    []byte(data.Message),
 			Attempts:   attempts,
 			ExpireAt:   time.Now().Add(data.Expire.Duration()),
 		}
 
 		secretID, err := h.secrets.Store(r.Context(), request)
+		clear(request.Message)
 		if err == nil {
-			secretURL := fmt.Sprintf("%s/%s", r.Header.Get("origin"), secretID)
+			secretURL := fmt.Sprintf("%s/%s", proxy.BaseURL(r), secretID)
 			page := sharePage(secretURL)
+			html.NoStore(w)
 
 			h.renderer.Component(r.Context(), w, http.StatusOK, page)
 
 			return
 		}
 
+		if errors.Is(err, ErrStoreFull) {
+			h.renderer.Component(r.Context(), w, http.StatusServiceUnavailable, capacityPage())
+
+			return
+		}
+
 		h.renderer.ServerError(r.Context(), w, err)
 
 		return
@@ -67,31 +77,33 @@ func (h *Handler) Share(w http.ResponseWriter, r *http.Request) {
 	h.renderer.Component(r.Context(), w, http.StatusOK, createPage(data))
 }
 
-func (h *Handler) validateShareData(request createData) []string {
-	var violations []string
+func validateShareData(request createData) []i18n.Message {
+	var violations []i18n.Message
 
 	const maxPassphraseLen = 32
 	if len(request.Passphrase) > maxPassphraseLen {
-		violations = append(violations, "The passphrase must be less than or equal to 32 bytes")
+		violations = append(violations, i18n.Msg("violation.passphrase_too_long", maxPassphraseLen))
 	}
 
 	const maxMessageLen = 4 * 1024
 	if len(request.Message) > maxMessageLen {
-		violations = append(violations, "The message must be less than or equal to 4 kilobytes")
+		violations = append(violations, i18n.Msg("violation.message_too_long", maxMessageLen/1024))
 	}
 
 	if request.Expire.Duration() <= 0 {
-		violations = append(violations, "The expire field must be positive")
+		violations = append(violations, i18n.Msg("violation.expire_not_positive"))
 	}
 
 	if request.Expire.Duration() > 24*time.Hour {
-		violations = append(violations, "Expire must be less than 1 day")
+		violations = append(violations, i18n.Msg("violation.expire_too_long"))
 	}
 
 	return violations
 }
 
 func (h *Handler) Open(w http.ResponseWriter, r *http.Request) {
+	html.NoStore(w)
+
 	data := openData{Passphrase: r.Form.Get("passphrase")}
 
 	if r.Method == http.MethodPost {
@@ -105,12 +117,13 @@ func (h *Handler) Open(w http.ResponseWriter, r *http.Request) {
 			page := viewPage(message)
 
 			h.renderer.Component(r.Context(), w, http.StatusOK, page)
+			clear(message)
 
 			return
 		}
 
 		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) || errors.Is(err, ErrInvalidPassphrase) {
-			data.Violations = append(data.Violations, "Message not found or invalid passphrase")
+			data.Violations = append(data.Violations, i18n.Msg("violation.not_found"))
 		} else {
 			h.renderer.ServerError(r.Context(), w, err)
 
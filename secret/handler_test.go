@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/html"
+	"github.com/pugkong/sharesecrets/i18n"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Share(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	share := func(handler *Handler) *httptest.ResponseRecorder {
+		form := url.Values{"message": {"Message"}, "passphrase": {"Passphrase"}}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		_ = r.ParseForm()
+
+		w := httptest.NewRecorder()
+		handler.Share(w, r)
+
+		return w
+	}
+
+	t.Run("it renders capacity page when store is full", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{MaxSecrets: 1})
+		service := NewService(logger, NewSecretboxEncryptor(logger), store, CleanupSchedule{Interval: time.Minute}, time.Now)
+		handler := NewHandler(service, html.NewRenderer(logger))
+
+		w := share(handler)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = share(handler)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestHandler_NoStore(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := NewService(
+		logger,
+		NewSecretboxEncryptor(logger),
+		NewInMemoryStore(logger, InMemoryLimits{}),
+		CleanupSchedule{Interval: time.Minute},
+		time.Now,
+	)
+	handler := NewHandler(service, html.NewRenderer(logger))
+
+	t.Run("it disables caching of open page", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/key", nil)
+		r.SetPathValue("key", "key")
+		_ = r.ParseForm()
+
+		w := httptest.NewRecorder()
+		handler.Open(w, r)
+
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("it disables caching of shared link page", func(t *testing.T) {
+		form := url.Values{"message": {"Message"}, "passphrase": {"Passphrase"}}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		_ = r.ParseForm()
+
+		w := httptest.NewRecorder()
+		handler.Share(w, r)
+
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+}
+
+func TestValidateShareData(t *testing.T) {
+	ctx := context.Background()
+
+	violations := validateShareData(createData{
+		Passphrase: strings.Repeat("p", 33),
+		Message:    strings.Repeat("m", 4*1024+1),
+		Expire:     createExpireData{Amount: "0", Unit: "hours"},
+	})
+
+	var messages []string
+	for _, violation := range violations {
+		messages = append(messages, i18n.Translate(ctx, violation))
+	}
+	require.Equal(
+		t,
+		[]string{
+			"The passphrase must be less than or equal to 32 bytes",
+			"The message must be less than or equal to 4 kilobytes",
+			"The expire field must be positive",
+		},
+		messages,
+	)
+}
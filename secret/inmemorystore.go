@@ -1,49 +1,137 @@
 package secret
 
 import (
+	"bytes"
+	"container/heap"
 	"context"
+	"fmt"
+	"hash/maphash"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/pugkong/sharesecrets/tracing"
 )
 
 var _ Store = &InMemoryStore{}
 
+const inMemoryShards = 64
+
+type InMemoryLimits struct {
+	MaxSecrets int
+	MaxBytes   int64
+}
+
+type InMemoryUsage struct {
+	Secrets int
+	Bytes   int64
+	Limits  InMemoryLimits
+}
+
 type InMemoryStore struct {
-	lock   sync.Mutex
 	logger *slog.Logger
-	data   map[string]Secret
+	limits InMemoryLimits
+	seed   maphash.Seed
+	shards [inMemoryShards]inMemoryShard
+	count  atomic.Int64
+	bytes  atomic.Int64
+
+	journal func(key string, attempts int) error
+	purged  func(ctx context.Context) error
+}
+
+type inMemoryShard struct {
+	lock   sync.Mutex
+	data   map[string]*inMemoryEntry
+	expiry expiryHeap
 }
 
-func NewInMemoryStore(logger *slog.Logger) *InMemoryStore {
-	return &InMemoryStore{
+type inMemoryEntry struct {
+	key    string
+	secret Secret
+	index  int
+}
+
+func NewInMemoryStore(logger *slog.Logger, limits InMemoryLimits) *InMemoryStore {
+	store := &InMemoryStore{
 		logger: logger,
-		data:   make(map[string]Secret),
+		limits: limits,
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range store.shards {
+		store.shards[i].data = make(map[string]*inMemoryEntry)
 	}
+
+	return store
 }
 
 func (s *InMemoryStore) Load(ctx context.Context, key string) (Secret, error) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	ctx, span := tracing.Start(ctx, tracerName, "InMemoryStore.Load")
+	defer span.End()
+
+	shard := s.shard(key)
+	shard.lock.Lock()
+	var secret Secret
+	entry, ok := shard.data[key]
+	if ok {
+		secret = entry.secret
+		secret.data = bytes.Clone(secret.data)
+	}
+	shard.lock.Unlock()
 
-	secret, ok := s.data[key]
 	if !ok {
-		s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret not found", slog.String("key", key))
+		s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret not found", keyAttr(key))
 
 		return secret, ErrNotFound
 	}
-	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret loaded", slog.String("key", key))
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret loaded", keyAttr(key))
 
 	return secret, nil
 }
 
 func (s *InMemoryStore) Save(ctx context.Context, key string, secret Secret) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	ctx, span := tracing.Start(ctx, tracerName, "InMemoryStore.Save")
+	defer span.End()
+
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	entry, exists := shard.data[key]
+	var size int64
+	if exists {
+		size = int64(len(entry.secret.data))
+	}
+	if !s.reserve(exists, int64(len(secret.data))-size) {
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "Secret rejected, store is full",
+			keyAttr(key),
+			slog.Int64("secrets", s.count.Load()),
+			slog.Int64("bytes", s.bytes.Load()),
+		)
+
+		return ErrStoreFull
+	}
+	if exists {
+		if err := s.record(ctx, key, secret.attempts); err != nil {
+			s.bytes.Add(size - int64(len(secret.data)))
 
-	s.data[key] = secret
+			return err
+		}
+	}
+
+	secret.data = bytes.Clone(secret.data)
+	if exists {
+		clear(entry.secret.data)
+		entry.secret = secret
+		heap.Fix(&shard.expiry, entry.index)
+	} else {
+		entry = &inMemoryEntry{key: key, secret: secret}
+		shard.data[key] = entry
+		heap.Push(&shard.expiry, entry)
+	}
 	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret saved",
-		slog.String("key", key),
+		keyAttr(key),
 		slog.String("expireAt", secret.exp.Format(time.RFC3339)),
 	)
 
@@ -51,26 +139,201 @@ func (s *InMemoryStore) Save(ctx context.Context, key string, secret Secret) err
 }
 
 func (s *InMemoryStore) Remove(ctx context.Context, key string) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	ctx, span := tracing.Start(ctx, tracerName, "InMemoryStore.Remove")
+	defer span.End()
 
-	delete(s.data, key)
-	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret removed", slog.String("key", key))
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	if entry, ok := shard.data[key]; ok {
+		if err := s.record(ctx, key, 0); err != nil {
+			return err
+		}
+		heap.Remove(&shard.expiry, entry.index)
+		s.release(entry.secret)
+		delete(shard.data, key)
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secret removed", keyAttr(key))
 
 	return nil
 }
 
-func (s *InMemoryStore) Cleanup(ctx context.Context) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+func (s *InMemoryStore) Cleanup(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "InMemoryStore.Cleanup")
+	defer span.End()
+
+	var removed int
+	now := time.Now()
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.lock.Lock()
+		for shard.expiry.Len() > 0 && now.After(shard.expiry[0].secret.exp) {
+			entry := heap.Pop(&shard.expiry).(*inMemoryEntry) //nolint:forcetypeassert
+
+			s.release(entry.secret)
+			delete(shard.data, entry.key)
+			removed++
+
+			s.logger.LogAttrs(ctx, slog.LevelDebug, "Expired secret removed", keyAttr(entry.key))
+		}
+		shard.lock.Unlock()
+	}
+
+	return removed, nil
+}
+
+func (s *InMemoryStore) Stats(ctx context.Context) (Stats, error) {
+	_, span := tracing.Start(ctx, tracerName, "InMemoryStore.Stats")
+	defer span.End()
+
+	var stats Stats
+	now := time.Now()
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.lock.Lock()
+		for _, entry := range shard.data {
+			if now.After(entry.secret.exp) {
+				stats.Expired++
+			} else {
+				stats.Active++
+			}
+			stats.Bytes += int64(len(entry.secret.data))
+		}
+		shard.lock.Unlock()
+	}
+
+	return stats, nil
+}
+
+func (s *InMemoryStore) Purge(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "InMemoryStore.Purge")
+	defer span.End()
+
+	var count int
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.lock.Lock()
+		for _, entry := range shard.data {
+			s.release(entry.secret)
+		}
+		count += len(shard.data)
+		clear(shard.data)
+		shard.expiry = nil
+		shard.lock.Unlock()
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "Secrets purged", slog.Int("count", count))
 
-	for key, secret := range s.data {
-		if time.Now().After(secret.exp) {
-			delete(s.data, key)
+	if s.purged != nil {
+		if err := s.purged(ctx); err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "Failed to persist purge", slog.String("error", err.Error()))
 
-			s.logger.LogAttrs(ctx, slog.LevelDebug, "Expired secret removed", slog.String("key", key))
+			return count, tracing.Fail(span, fmt.Errorf("persist purge: %w", err))
 		}
 	}
 
+	return count, nil
+}
+
+func (s *InMemoryStore) Usage() InMemoryUsage {
+	return InMemoryUsage{
+		Secrets: int(s.count.Load()),
+		Bytes:   s.bytes.Load(),
+		Limits:  s.limits,
+	}
+}
+
+func (s *InMemoryStore) shard(key string) *inMemoryShard {
+	return &s.shards[maphash.String(s.seed, key)%inMemoryShards]
+}
+
+func (s *InMemoryStore) record(ctx context.Context, key string, attempts int) error {
+	if s.journal == nil {
+		return nil
+	}
+
+	if err := s.journal(key, attempts); err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "Failed to journal secret change", keyAttr(key), slog.String("error", err.Error()))
+
+		return fmt.Errorf("journal secret change: %w", err)
+	}
+
 	return nil
 }
+
+func (s *InMemoryStore) reserve(exists bool, size int64) bool {
+	if !exists {
+		if count := s.count.Add(1); s.limits.MaxSecrets > 0 && count > int64(s.limits.MaxSecrets) {
+			s.count.Add(-1)
+
+			return false
+		}
+	}
+
+	if total := s.bytes.Add(size); s.limits.MaxBytes > 0 && size > 0 && total > s.limits.MaxBytes {
+		s.bytes.Add(-size)
+		if !exists {
+			s.count.Add(-1)
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func (s *InMemoryStore) release(secret Secret) {
+	s.count.Add(-1)
+	s.bytes.Add(-int64(len(secret.data)))
+	clear(secret.data)
+}
+
+func (s *InMemoryStore) snapshot() []snapshotEntry {
+	entries := make([]snapshotEntry, 0, s.count.Load())
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.lock.Lock()
+		for key, entry := range shard.data {
+			entries = append(entries, snapshotEntry{
+				Key:      key,
+				Data:     bytes.Clone(entry.secret.data),
+				Attempts: entry.secret.attempts,
+				Exp:      entry.secret.exp.UnixNano(),
+			})
+		}
+		shard.lock.Unlock()
+	}
+
+	return entries
+}
+
+type expiryHeap []*inMemoryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].secret.exp.Before(h[j].secret.exp) }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	entry := x.(*inMemoryEntry) //nolint:forcetypeassert
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	entry.index = -1
+	*h = old[:len(old)-1]
+
+	return entry
+}
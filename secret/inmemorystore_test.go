@@ -2,8 +2,10 @@ package secret
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,52 +16,251 @@ func TestInMemoryStore(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	ctx := context.Background()
 
-	t.Run("it saves, loads and removes items", func(t *testing.T) {
-		store := NewInMemoryStore(logger)
+	t.Run("it reports stats", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
 
-		const key = "key"
-		saveSecret := Secret{data: []byte("store test")}
-		err := store.Save(ctx, key, saveSecret)
+		err := store.Save(ctx, "expired", Secret{data: []byte("12345"), exp: time.Now().Add(-time.Minute)})
 		require.NoError(t, err)
 
-		loadSecret, err := store.Load(ctx, key)
+		err = store.Save(ctx, "active", Secret{data: []byte("123"), exp: time.Now().Add(time.Minute)})
 		require.NoError(t, err)
-		require.Equal(t, saveSecret, loadSecret)
 
-		err = store.Remove(ctx, key)
+		stats, err := store.Stats(ctx)
 		require.NoError(t, err)
+		require.Equal(t, Stats{Active: 1, Expired: 1, Bytes: 8}, stats)
+	})
+
+	t.Run("it purges all items", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+
+		err := store.Save(ctx, "first", Secret{exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "second", Secret{exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		count, err := store.Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
 
-		_, err = store.Load(ctx, key)
-		require.Error(t, err)
+		_, err = store.Load(ctx, "first")
 		require.ErrorIs(t, err, ErrNotFound)
 	})
 
-	t.Run("remove method doesn't produce error when item not exist", func(t *testing.T) {
-		store := NewInMemoryStore(logger)
+	t.Run("it rejects secrets over the count limit", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{MaxSecrets: 1})
+
+		err := store.Save(ctx, "first", Secret{data: []byte("data")})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "second", Secret{data: []byte("data")})
+		require.ErrorIs(t, err, ErrStoreFull)
+
+		err = store.Save(ctx, "first", Secret{data: []byte("data"), attempts: 1})
+		require.NoError(t, err)
+
+		err = store.Remove(ctx, "first")
+		require.NoError(t, err)
 
-		err := store.Remove(ctx, "key")
+		err = store.Save(ctx, "second", Secret{data: []byte("data")})
 		require.NoError(t, err)
 	})
 
-	t.Run("it removes expired items", func(t *testing.T) {
-		store := NewInMemoryStore(logger)
+	t.Run("it rejects secrets over the bytes limit", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{MaxBytes: 8})
 
-		const expiredSecretKey = "expired"
-		err := store.Save(ctx, expiredSecretKey, Secret{exp: time.Now().Add(-time.Minute)})
+		err := store.Save(ctx, "first", Secret{data: []byte("12345"), exp: time.Now().Add(-time.Minute)})
 		require.NoError(t, err)
 
-		const activeSecretKey = "active"
-		err = store.Save(ctx, activeSecretKey, Secret{exp: time.Now().Add(time.Minute)})
+		err = store.Save(ctx, "second", Secret{data: []byte("1234")})
+		require.ErrorIs(t, err, ErrStoreFull)
+
+		_, err = store.Cleanup(ctx)
 		require.NoError(t, err)
 
-		err = store.Cleanup(ctx)
+		err = store.Save(ctx, "second", Secret{data: []byte("1234")})
 		require.NoError(t, err)
+	})
 
-		_, err = store.Load(ctx, expiredSecretKey)
-		require.Error(t, err)
-		require.ErrorIs(t, err, ErrNotFound)
+	t.Run("it reports usage", func(t *testing.T) {
+		limits := InMemoryLimits{MaxSecrets: 10, MaxBytes: 100}
+		store := NewInMemoryStore(logger, limits)
+		require.Equal(t, InMemoryUsage{Limits: limits}, store.Usage())
+
+		err := store.Save(ctx, "first", Secret{data: []byte("12345")})
+		require.NoError(t, err)
 
-		_, err = store.Load(ctx, activeSecretKey)
+		err = store.Save(ctx, "second", Secret{data: []byte("123")})
 		require.NoError(t, err)
+
+		err = store.Save(ctx, "second", Secret{data: []byte("1")})
+		require.NoError(t, err)
+		require.Equal(t, InMemoryUsage{Secrets: 2, Bytes: 6, Limits: limits}, store.Usage())
+
+		_, err = store.Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, InMemoryUsage{Limits: limits}, store.Usage())
+	})
+
+	t.Run("it isolates and wipes stored buffers", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+
+		data := []byte("ciphertext")
+		err := store.Save(ctx, "key", Secret{data: data, exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		clear(data)
+
+		loaded, err := store.Load(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("ciphertext"), loaded.data)
+		clear(loaded.data)
+
+		stored := store.shard("key").data["key"].secret.data
+		require.Equal(t, []byte("ciphertext"), stored)
+
+		err = store.Remove(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, len(stored)), stored)
+
+		err = store.Save(ctx, "expired", Secret{data: []byte("ciphertext"), exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+		stored = store.shard("expired").data["expired"].secret.data
+
+		_, err = store.Cleanup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, len(stored)), stored)
 	})
+
+	t.Run("it follows expiry changes of overwritten items", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+
+		err := store.Save(ctx, "extended", Secret{exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "extended", Secret{exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "shortened", Secret{exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "shortened", Secret{exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+
+		removed, err := store.Cleanup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+
+		_, err = store.Load(ctx, "extended")
+		require.NoError(t, err)
+
+		_, err = store.Load(ctx, "shortened")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("it keeps one expiry entry per secret", func(t *testing.T) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+
+		for i := range 100 {
+			key := fmt.Sprintf("key-%d", i%3)
+			require.NoError(t, store.Save(ctx, key, Secret{exp: time.Now().Add(time.Duration(i) * time.Minute)}))
+			if i%2 == 0 {
+				require.NoError(t, store.Remove(ctx, key))
+			}
+		}
+
+		var secrets, entries int
+		for i := range store.shards {
+			secrets += len(store.shards[i].data)
+			entries += store.shards[i].expiry.Len()
+		}
+		require.Equal(t, store.Usage().Secrets, secrets)
+		require.Equal(t, secrets, entries)
+	})
+}
+
+func fillInMemoryStore(b *testing.B, store *InMemoryStore, count, expired int) {
+	b.Helper()
+
+	ctx := context.Background()
+	data := make([]byte, 64)
+	for i := range count {
+		exp := time.Now().Add(time.Hour)
+		if i < expired {
+			exp = time.Now().Add(-time.Minute)
+		}
+
+		if err := store.Save(ctx, fmt.Sprintf("key-%d", i), Secret{data: data, exp: exp}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInMemoryStore_Cleanup(b *testing.B) {
+	const (
+		secrets = 1_000_000
+		expired = 10_000
+	)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	store := NewInMemoryStore(logger, InMemoryLimits{})
+	fillInMemoryStore(b, store, secrets, 0)
+
+	b.ResetTimer()
+	for range b.N {
+		b.StopTimer()
+		for i := range expired {
+			err := store.Save(ctx, fmt.Sprintf("expired-%d", i), Secret{exp: time.Now().Add(-time.Minute)})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StartTimer()
+
+		removed, err := store.Cleanup(ctx)
+		if err != nil || removed != expired {
+			b.Fatalf("removed %d secrets: %v", removed, err)
+		}
+	}
+}
+
+func BenchmarkInMemoryStore_LoadDuringCleanup(b *testing.B) {
+	const secrets = 1_000_000
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	store := NewInMemoryStore(logger, InMemoryLimits{})
+	fillInMemoryStore(b, store, secrets, secrets/10)
+
+	stop := make(chan struct{})
+	cleanups := make(chan struct{})
+	go func() {
+		defer close(cleanups)
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if _, err := store.Cleanup(ctx); err != nil {
+					b.Error(err)
+				}
+			}
+		}
+	}()
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			key := fmt.Sprintf("key-%d", secrets/10+next.Add(1)%(secrets-secrets/10))
+			if _, err := store.Load(ctx, key); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	b.StopTimer()
+
+	close(stop)
+	<-cleanups
 }
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS secrets (
+	key      CHAR(255)   PRIMARY KEY,
+	data     BYTEA       NOT NULL,
+	attempts SMALLINT    NOT NULL,
+	expireAt TIMESTAMPTZ NOT NULL
+);
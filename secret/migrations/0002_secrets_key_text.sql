@@ -0,0 +1 @@
+ALTER TABLE secrets ALTER COLUMN key TYPE TEXT USING rtrim(key);
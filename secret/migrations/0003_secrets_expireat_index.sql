@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS secrets_expireat_idx ON secrets (expireAt);
@@ -0,0 +1,122 @@
+package secret
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pgMigrationsLockID = 7_315_620_413
+
+//go:embed migrations/*.sql
+var pgMigrationsFS embed.FS
+
+type pgMigration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadPgMigrations(fsys fs.FS) ([]pgMigration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	migrations := make([]pgMigration, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".sql")
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version prefix", name)
+		}
+
+		sql, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, pgMigration{version: version, name: name, sql: string(sql)})
+	}
+
+	slices.SortFunc(migrations, func(a, b pgMigration) int { return a.version - b.version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("migrations %s and %s share a version", migrations[i-1].name, migrations[i].name)
+		}
+	}
+
+	return migrations, nil
+}
+
+func migratePg(ctx context.Context, pool *pgxpool.Pool, migrations []pgMigration) (err error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", pgMigrationsLockID); err != nil {
+		return fmt.Errorf("acquire migrations lock: %w", err)
+	}
+	defer func() {
+		_, unlockErr := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", pgMigrationsLockID)
+		if unlockErr != nil && err == nil {
+			err = fmt.Errorf("release migrations lock: %w", unlockErr)
+		}
+	}()
+
+	sql := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version   INTEGER     PRIMARY KEY,
+			name      TEXT        NOT NULL,
+			appliedAt TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	rows, _ := conn.Query(ctx, "SELECT version FROM schema_migrations")
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("select applied migrations: %w", err)
+	}
+
+	for _, migration := range migrations {
+		if slices.Contains(versions, migration.version) {
+			continue
+		}
+
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.sql); err != nil {
+				return fmt.Errorf("migration %s: %w", migration.name, err)
+			}
+
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+				migration.version,
+				migration.name,
+			)
+			if err != nil {
+				return fmt.Errorf("record migration %s: %w", migration.name, err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPgMigrations(t *testing.T) {
+	t.Run("it loads embedded migrations in order", func(t *testing.T) {
+		migrations, err := loadPgMigrations(pgMigrationsFS)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		for i, migration := range migrations {
+			require.Equal(t, i+1, migration.version, migration.name)
+			require.NotEmpty(t, migration.sql, migration.name)
+		}
+	})
+
+	tests := map[string]struct {
+		files    fstest.MapFS
+		versions []int
+		err      string
+	}{
+		"sorted by version": {
+			files: fstest.MapFS{
+				"migrations/0010_last.sql":   {Data: []byte("SELECT 10")},
+				"migrations/0002_second.sql": {Data: []byte("SELECT 2")},
+				"migrations/0001_first.sql":  {Data: []byte("SELECT 1")},
+				"migrations/README.md":       {Data: []byte("ignored")},
+			},
+			versions: []int{1, 2, 10},
+		},
+		"invalid version": {
+			files: fstest.MapFS{"migrations/first.sql": {Data: []byte("SELECT 1")}},
+			err:   "migration first: invalid version prefix",
+		},
+		"duplicated version": {
+			files: fstest.MapFS{
+				"migrations/0001_first.sql": {Data: []byte("SELECT 1")},
+				"migrations/1_again.sql":    {Data: []byte("SELECT 1")},
+			},
+			err: "share a version",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			migrations, err := loadPgMigrations(test.files)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+
+				return
+			}
+			require.NoError(t, err)
+
+			versions := make([]int, 0, len(migrations))
+			for _, migration := range migrations {
+				versions = append(versions, migration.version)
+			}
+			require.Equal(t, test.versions, versions)
+		})
+	}
+}
@@ -4,41 +4,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pugkong/sharesecrets/tracing"
 )
 
 var _ Store = &PgStore{}
 
+const pgCleanupLockID = 7_315_620_414
+
+type PgCleanupConfig struct {
+	BatchSize int
+	Pause     time.Duration
+	Cutoff    time.Duration
+}
+
 type PgStore struct {
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	cleanup PgCleanupConfig
 }
 
-func NewPgStore(pool *pgxpool.Pool) *PgStore {
+func NewPgStore(pool *pgxpool.Pool, cleanup PgCleanupConfig) *PgStore {
 	return &PgStore{
-		pool: pool,
+		pool:    pool,
+		cleanup: cleanup,
 	}
 }
 
 func (p *PgStore) Init(ctx context.Context) error {
-	sql := `
-		CREATE TABLE IF NOT EXISTS secrets (
-			key      CHAR(255)   PRIMARY KEY,
-			data     BYTEA       NOT NULL,
-			attempts SMALLINT    NOT NULL,
-			expireAt TIMESTAMPTZ NOT NULL
-		)
-	`
-	_, err := p.pool.Exec(ctx, sql)
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Init")
+	defer span.End()
+
+	migrations, err := loadPgMigrations(pgMigrationsFS)
 	if err != nil {
-		return fmt.Errorf("initialize db: %w", err)
+		return tracing.Fail(span, err)
+	}
+
+	if err := migratePg(ctx, p.pool, migrations); err != nil {
+		return tracing.Fail(span, fmt.Errorf("migrate db: %w", err))
 	}
 
 	return nil
 }
 
 func (p *PgStore) Load(ctx context.Context, key string) (Secret, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Load")
+	defer span.End()
+
 	secret := Secret{}
 	row := p.pool.QueryRow(ctx, "SELECT data, attempts, expireAt FROM secrets WHERE key=$1", key)
 	err := row.Scan(&secret.data, &secret.attempts, &secret.exp)
@@ -46,13 +60,16 @@ func (p *PgStore) Load(ctx context.Context, key string) (Secret, error) {
 		return secret, ErrNotFound
 	}
 	if err != nil {
-		return secret, fmt.Errorf("select query: %w", err)
+		return secret, tracing.Fail(span, fmt.Errorf("select query: %w", err))
 	}
 
 	return secret, nil
 }
 
 func (p *PgStore) Save(ctx context.Context, key string, secret Secret) error {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Save")
+	defer span.End()
+
 	sql := `
 		INSERT INTO secrets (key, data, attempts, expireAt)
 		VALUES (@key, @data, @attempts, @expireAt)
@@ -66,26 +83,114 @@ func (p *PgStore) Save(ctx context.Context, key string, secret Secret) error {
 		"expireAt": secret.exp,
 	})
 	if err != nil {
-		return fmt.Errorf("upsert query: %w", err)
+		return tracing.Fail(span, fmt.Errorf("upsert query: %w", err))
 	}
 
 	return nil
 }
 
 func (p *PgStore) Remove(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Remove")
+	defer span.End()
+
 	_, err := p.pool.Exec(ctx, "DELETE FROM secrets WHERE key=$1", key)
 	if err != nil {
-		return fmt.Errorf("delete by key query: %w", err)
+		return tracing.Fail(span, fmt.Errorf("delete by key query: %w", err))
 	}
 
 	return nil
 }
 
-func (p *PgStore) Cleanup(ctx context.Context) error {
-	_, err := p.pool.Exec(ctx, "DELETE FROM secrets WHERE expireAt < now()")
+func (p *PgStore) Cleanup(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Cleanup")
+	defer span.End()
+
+	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("delete expired query: %w", err)
+		return 0, tracing.Fail(span, fmt.Errorf("acquire connection: %w", err))
 	}
+	defer conn.Release()
 
-	return nil
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", pgCleanupLockID).Scan(&locked); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("acquire cleanup lock: %w", err))
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	removed, err := p.deleteExpired(ctx, conn)
+	if _, unlockErr := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", pgCleanupLockID); unlockErr != nil {
+		err = errors.Join(err, fmt.Errorf("release cleanup lock: %w", unlockErr))
+	}
+	if err != nil {
+		return removed, tracing.Fail(span, err)
+	}
+
+	return removed, nil
+}
+
+func (p *PgStore) deleteExpired(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	sql := `
+		DELETE FROM secrets
+		WHERE key IN (
+			SELECT key FROM secrets
+			WHERE expireAt < now()
+			ORDER BY expireAt
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	var removed int
+	deadline := time.Now().Add(p.cleanup.Cutoff)
+	for {
+		tag, err := conn.Exec(ctx, sql, p.cleanup.BatchSize)
+		if err != nil {
+			return removed, fmt.Errorf("delete expired batch query: %w", err)
+		}
+		removed += int(tag.RowsAffected())
+
+		if tag.RowsAffected() < int64(p.cleanup.BatchSize) || !time.Now().Before(deadline) {
+			return removed, nil
+		}
+
+		select {
+		case <-time.After(p.cleanup.Pause):
+		case <-ctx.Done():
+			return removed, fmt.Errorf("delete expired pause: %w", ctx.Err())
+		}
+	}
+}
+
+func (p *PgStore) Stats(ctx context.Context) (Stats, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Stats")
+	defer span.End()
+
+	sql := `
+		SELECT
+			count(*) FILTER (WHERE expireAt >= now()),
+			count(*) FILTER (WHERE expireAt < now()),
+			coalesce(sum(octet_length(data)), 0)
+		FROM secrets
+	`
+	var stats Stats
+	err := p.pool.QueryRow(ctx, sql).Scan(&stats.Active, &stats.Expired, &stats.Bytes)
+	if err != nil {
+		return stats, tracing.Fail(span, fmt.Errorf("stats query: %w", err))
+	}
+
+	return stats, nil
+}
+
+func (p *PgStore) Purge(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "PgStore.Purge")
+	defer span.End()
+
+	tag, err := p.pool.Exec(ctx, "DELETE FROM secrets")
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("delete all query: %w", err))
+	}
+
+	return int(tag.RowsAffected()), nil
 }
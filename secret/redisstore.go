@@ -0,0 +1,216 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pugkong/sharesecrets/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ Store               = &RedisStore{}
+	_ AttemptsDecrementer = &RedisStore{}
+)
+
+const redisKeyPrefix = "sharesecrets:secret:"
+
+var decrementAttemptsScript = redis.NewScript(`
+	local value = redis.call('GET', KEYS[1])
+	if not value then
+		return -1
+	end
+
+	local secret = cjson.decode(value)
+	secret.attempts = secret.attempts - 1
+	if secret.attempts <= 0 then
+		redis.call('DEL', KEYS[1])
+		return 0
+	end
+
+	redis.call('SET', KEYS[1], cjson.encode(secret), 'KEEPTTL')
+	return secret.attempts
+`)
+
+type redisSecret struct {
+	Data     []byte `json:"data"`
+	Attempts int    `json:"attempts"`
+	Exp      int64  `json:"exp"`
+}
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Load(ctx context.Context, key string) (Secret, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.Load")
+	defer span.End()
+
+	secret, err := s.get(ctx, redisKeyPrefix+key)
+	if errors.Is(err, ErrNotFound) {
+		return secret, ErrNotFound
+	}
+	if err != nil {
+		return secret, tracing.Fail(span, err)
+	}
+
+	return secret, nil
+}
+
+func (s *RedisStore) Save(ctx context.Context, key string, secret Secret) error {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.Save")
+	defer span.End()
+
+	value, err := json.Marshal(redisSecret{
+		Data:     secret.data,
+		Attempts: secret.attempts,
+		Exp:      secret.exp.UnixMilli(),
+	})
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("encode secret: %w", err))
+	}
+
+	err = s.client.Do(ctx, "SET", redisKeyPrefix+key, value, "PXAT", secret.exp.UnixMilli()).Err()
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("set command: %w", err))
+	}
+
+	return nil
+}
+
+func (s *RedisStore) Remove(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.Remove")
+	defer span.End()
+
+	if err := s.client.Del(ctx, redisKeyPrefix+key).Err(); err != nil {
+		return tracing.Fail(span, fmt.Errorf("del command: %w", err))
+	}
+
+	return nil
+}
+
+func (s *RedisStore) DecrementAttempts(ctx context.Context, key string) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.DecrementAttempts")
+	defer span.End()
+
+	attempts, err := decrementAttemptsScript.Run(ctx, s.client, []string{redisKeyPrefix + key}).Int()
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("decrement attempts script: %w", err))
+	}
+	if attempts < 0 {
+		return 0, ErrNotFound
+	}
+
+	return attempts, nil
+}
+
+func (s *RedisStore) Cleanup(ctx context.Context) (int, error) {
+	_, span := tracing.Start(ctx, tracerName, "RedisStore.Cleanup")
+	defer span.End()
+
+	return 0, nil
+}
+
+func (s *RedisStore) Stats(ctx context.Context) (Stats, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.Stats")
+	defer span.End()
+
+	var stats Stats
+	err := s.scan(ctx, func(keys []string) error {
+		for _, key := range keys {
+			secret, err := s.get(ctx, key)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if time.Now().After(secret.exp) {
+				stats.Expired++
+			} else {
+				stats.Active++
+			}
+			stats.Bytes += int64(len(secret.data))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return stats, tracing.Fail(span, err)
+	}
+
+	return stats, nil
+}
+
+func (s *RedisStore) Purge(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "RedisStore.Purge")
+	defer span.End()
+
+	var count int
+	err := s.scan(ctx, func(keys []string) error {
+		removed, err := s.client.Del(ctx, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("del command: %w", err)
+		}
+		count += int(removed)
+
+		return nil
+	})
+	if err != nil {
+		return count, tracing.Fail(span, err)
+	}
+
+	return count, nil
+}
+
+func (s *RedisStore) get(ctx context.Context, key string) (Secret, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Secret{}, ErrNotFound
+	}
+	if err != nil {
+		return Secret{}, fmt.Errorf("get command: %w", err)
+	}
+
+	var secret redisSecret
+	if err := json.Unmarshal(value, &secret); err != nil {
+		return Secret{}, fmt.Errorf("decode secret: %w", err)
+	}
+
+	return Secret{
+		data:     secret.Data,
+		attempts: secret.Attempts,
+		exp:      time.UnixMilli(secret.Exp),
+	}, nil
+}
+
+func (s *RedisStore) scan(ctx context.Context, fn func(keys []string) error) error {
+	const batchSize = 1000
+
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, redisKeyPrefix+"*", batchSize).Result()
+		if err != nil {
+			return fmt.Errorf("scan command: %w", err)
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,121 @@
+package secret
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+
+	newStore := func(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+		t.Helper()
+
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		return NewRedisStore(client), server
+	}
+
+	t.Run("it lets redis expire items", func(t *testing.T) {
+		store, server := newStore(t)
+
+		err := store.Save(ctx, "key", Secret{data: []byte{}, attempts: 1, exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		require.InDelta(t, time.Minute, server.TTL(redisKeyPrefix+"key"), float64(time.Second))
+
+		server.FastForward(time.Minute + time.Second)
+
+		_, err = store.Load(ctx, "key")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		removed, err := store.Cleanup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, removed)
+	})
+
+	t.Run("it decrements attempts atomically", func(t *testing.T) {
+		store, server := newStore(t)
+
+		err := store.Save(ctx, "key", Secret{data: []byte("data"), attempts: 2, exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		attempts, err := store.DecrementAttempts(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, 1, attempts)
+
+		secret, err := store.Load(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, 1, secret.attempts)
+		require.Equal(t, []byte("data"), secret.data)
+		require.Positive(t, server.TTL(redisKeyPrefix+"key"))
+
+		attempts, err = store.DecrementAttempts(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, 0, attempts)
+
+		_, err = store.Load(ctx, "key")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.DecrementAttempts(ctx, "key")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("it reports stats and purges items", func(t *testing.T) {
+		store, server := newStore(t)
+		require.NoError(t, server.Set("unrelated", "value"))
+
+		err := store.Save(ctx, "first", Secret{data: []byte("12345"), exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		err = store.Save(ctx, "second", Secret{data: []byte("123"), exp: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+
+		stats, err := store.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, Stats{Active: 2, Bytes: 8}, stats)
+
+		count, err := store.Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		stats, err = store.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, Stats{}, stats)
+		require.True(t, server.Exists("unrelated"))
+	})
+
+	t.Run("it is used by service to limit attempts", func(t *testing.T) {
+		const passphrase = "passphrase"
+
+		store, _ := newStore(t)
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		service := NewService(logger, NewSecretboxEncryptor(logger), store, CleanupSchedule{Interval: time.Minute}, time.Now)
+
+		key, err := service.Store(ctx, StoreRequest{
+			Passphrase: passphrase,
+			Message:    []byte("Message"),
+			Attempts:   2,
+			ExpireAt:   time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+
+		retrieveRequest := RetrieveRequest{Key: key, Passphrase: passphrase + passphrase}
+		_, err = service.Retrieve(ctx, retrieveRequest)
+		require.ErrorIs(t, err, ErrInvalidPassphrase)
+
+		_, err = service.Retrieve(ctx, retrieveRequest)
+		require.ErrorIs(t, err, ErrInvalidPassphrase)
+
+		_, err = service.Retrieve(ctx, RetrieveRequest{Key: key, Passphrase: passphrase})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
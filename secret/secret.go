@@ -4,23 +4,34 @@ import (
 	"cmp"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	mathrand "math/rand/v2"
+	"sync/atomic"
 	"time"
+
+	"github.com/pugkong/sharesecrets/tracing"
 )
 
+const tracerName = "github.com/pugkong/sharesecrets/secret"
+
 var (
 	ErrNotFound          = errors.New("not found")
 	ErrInvalidPassphrase = errors.New("invalid passphrase")
 	ErrExpired           = errors.New("expired")
+	ErrStoreFull         = errors.New("store is full")
+
+	errCleanupNotStarted = errors.New("cleanup loop is not running")
+	errCleanupStale      = errors.New("cleanup loop is stale")
 )
 
 type StoreRequest struct {
 	Passphrase string
-	Message    string
+	Message    []byte
 	Attempts   int
 	ExpireAt   time.Time
 }
@@ -36,45 +47,84 @@ type Secret struct {
 	exp      time.Time
 }
 
+func NewSecret(data []byte, attempts int, exp time.Time) Secret {
+	return Secret{data: data, attempts: attempts, exp: exp}
+}
+
+func (s Secret) Data() []byte {
+	return s.data
+}
+
+func (s Secret) Attempts() int {
+	return s.attempts
+}
+
+func (s Secret) ExpireAt() time.Time {
+	return s.exp
+}
+
 type Encryptor interface {
-	Encrypt(ctx context.Context, passpharse, message string) ([]byte, error)
-	Decrypt(ctx context.Context, passphrase string, data []byte) (string, error)
+	Encrypt(ctx context.Context, passpharse string, message []byte) ([]byte, error)
+	Decrypt(ctx context.Context, passphrase string, data []byte) ([]byte, error)
+}
+
+type Stats struct {
+	Active  int
+	Expired int
+	Bytes   int64
 }
 
 type Store interface {
 	Save(ctx context.Context, key string, secret Secret) error
 	Load(ctx context.Context, key string) (Secret, error)
 	Remove(ctx context.Context, key string) error
-	Cleanup(ctx context.Context) error
+	Cleanup(ctx context.Context) (int, error)
+	Stats(ctx context.Context) (Stats, error)
+	Purge(ctx context.Context) (int, error)
+}
+
+type AttemptsDecrementer interface {
+	DecrementAttempts(ctx context.Context, key string) (int, error)
+}
+
+type CleanupSchedule struct {
+	Interval time.Duration
+	Jitter   time.Duration
 }
 
 type Service struct {
-	logger          *slog.Logger
-	encryptor       Encryptor
-	store           Store
-	cleanupInterval time.Duration
-	now             func() time.Time
+	logger         *slog.Logger
+	encryptor      Encryptor
+	store          Store
+	cleanup        CleanupSchedule
+	now            func() time.Time
+	lastCleanup    atomic.Int64
+	cleanupTrigger chan struct{}
 }
 
-func NewService(logger *slog.Logger, encryptor Encryptor, store Store, cleanupInterval time.Duration, now func() time.Time) *Service {
+func NewService(logger *slog.Logger, encryptor Encryptor, store Store, cleanup CleanupSchedule, now func() time.Time) *Service {
 	return &Service{
-		logger:          logger,
-		encryptor:       encryptor,
-		store:           store,
-		cleanupInterval: cleanupInterval,
-		now:             now,
+		logger:         logger,
+		encryptor:      encryptor,
+		store:          store,
+		cleanup:        cleanup,
+		now:            now,
+		cleanupTrigger: make(chan struct{}, 1),
 	}
 }
 
 func (s *Service) Store(ctx context.Context, request StoreRequest) (string, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "Service.Store")
+	defer span.End()
+
 	key, err := s.generateStoreKey(ctx)
 	if err != nil {
-		return "", err
+		return "", tracing.Fail(span, err)
 	}
 
 	data, err := s.encryptMessage(ctx, request.Passphrase, request.Message)
 	if err != nil {
-		return "", err
+		return "", tracing.Fail(span, err)
 	}
 
 	secret := Secret{
@@ -83,65 +133,76 @@ func (s *Service) Store(ctx context.Context, request StoreRequest) (string, erro
 		exp:      request.ExpireAt,
 	}
 	if err := s.saveSecret(ctx, key, secret); err != nil {
-		return "", err
+		return "", tracing.Fail(span, err)
 	}
 
 	return key, nil
 }
 
-func (s *Service) Retrieve(ctx context.Context, request RetrieveRequest) (string, error) {
+func (s *Service) Retrieve(ctx context.Context, request RetrieveRequest) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "Service.Retrieve")
+	defer span.End()
+
+	message, err := s.retrieve(ctx, request)
+	if err != nil {
+		clear(message)
+
+		return nil, tracing.Fail(span, err)
+	}
+
+	return message, nil
+}
+
+func (s *Service) retrieve(ctx context.Context, request RetrieveRequest) ([]byte, error) {
 	secret, err := s.loadSecret(ctx, request.Key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer clear(secret.data)
 
 	if secret.exp.Before(s.now()) {
-		s.logger.LogAttrs(ctx, slog.LevelInfo, "Loaded secret is expired", slog.String("key", request.Key))
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "Loaded secret is expired", keyAttr(request.Key))
 
-		return "", ErrExpired
+		return nil, ErrExpired
 	}
 
 	message, err := s.decryptData(ctx, request.Passphrase, secret.data)
 	if err != nil && !errors.Is(err, ErrInvalidPassphrase) {
-		return "", err
+		return nil, err
 	}
 
 	if err == nil {
 		return message, s.removeSecret(ctx, request.Key)
 	}
 
+	if store, ok := s.store.(AttemptsDecrementer); ok {
+		return nil, cmp.Or(s.decrementAttempts(ctx, store, request.Key), err) //nolint:wrapcheck
+	}
+
 	secret.attempts--
 	if secret.attempts == 0 {
-		return message, cmp.Or(s.removeSecret(ctx, request.Key), err) //nolint:wrapcheck
+		return nil, cmp.Or(s.removeSecret(ctx, request.Key), err) //nolint:wrapcheck
 	}
 
-	return "", cmp.Or(s.saveSecret(ctx, request.Key, secret), err) //nolint:wrapcheck
+	return nil, cmp.Or(s.saveSecret(ctx, request.Key, secret), err) //nolint:wrapcheck
 }
 
 func (s *Service) CleanupLoop(ctx context.Context) error {
-	timer := time.NewTicker(s.cleanupInterval)
-	defer timer.Stop()
+	s.lastCleanup.Store(s.now().UnixNano())
 
 	s.logger.InfoContext(ctx, "Secrets cleanup loop started")
+	s.runCleanup(ctx, "startup")
+
+	timer := time.NewTimer(s.nextCleanup())
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-timer.C:
-			s.logger.InfoContext(ctx, "Secrets cleanup started")
-
-			start := time.Now()
-			err := s.store.Cleanup(ctx)
-			duration := time.Since(start)
-
-			if err == nil {
-				s.logger.LogAttrs(ctx, slog.LevelInfo, "Secrets cleanup completed",
-					slog.String("duration", duration.String()),
-				)
-			} else {
-				s.logger.LogAttrs(ctx, slog.LevelError, "Secrets cleanup loop error",
-					slog.String("error", err.Error()),
-					slog.String("duration", duration.String()),
-				)
-			}
+			s.runCleanup(ctx, "schedule")
+			timer.Reset(s.nextCleanup())
+		case <-s.cleanupTrigger:
+			s.runCleanup(ctx, "trigger")
 		case <-ctx.Done():
 			s.logger.InfoContext(ctx, "Secrets cleanup loop stopped")
 
@@ -150,8 +211,67 @@ func (s *Service) CleanupLoop(ctx context.Context) error {
 	}
 }
 
+func (s *Service) TriggerCleanup() bool {
+	select {
+	case s.cleanupTrigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Service) runCleanup(ctx context.Context, reason string) {
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "Secrets cleanup started", slog.String("reason", reason))
+
+	start := time.Now()
+	removed, err := s.store.Cleanup(ctx)
+	duration := time.Since(start)
+
+	if err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "Secrets cleanup loop error",
+			slog.String("error", err.Error()),
+			slog.String("reason", reason),
+			slog.Int("removed", removed),
+			slog.String("duration", duration.String()),
+		)
+
+		return
+	}
+
+	s.lastCleanup.Store(s.now().UnixNano())
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "Secrets cleanup completed",
+		slog.String("reason", reason),
+		slog.Int("removed", removed),
+		slog.String("duration", duration.String()),
+	)
+}
+
+func (s *Service) nextCleanup() time.Duration {
+	if s.cleanup.Jitter <= 0 {
+		return s.cleanup.Interval
+	}
+
+	return s.cleanup.Interval + mathrand.N(s.cleanup.Jitter)
+}
+
+func (s *Service) CheckCleanup(context.Context) error {
+	const staleIntervals = 3
+
+	last := s.lastCleanup.Load()
+	if last == 0 {
+		return errCleanupNotStarted
+	}
+
+	since := s.now().Sub(time.Unix(0, last))
+	if since > staleIntervals*(s.cleanup.Interval+s.cleanup.Jitter) {
+		return fmt.Errorf("%w: last run %s ago", errCleanupStale, since)
+	}
+
+	return nil
+}
+
 func (s *Service) loadSecret(ctx context.Context, key string) (Secret, error) {
-	logger := s.logger.With(slog.String("key", key))
+	logger := s.logger.With(keyAttr(key))
 
 	secret, err := s.store.Load(ctx, key)
 	if err != nil {
@@ -169,10 +289,14 @@ func (s *Service) loadSecret(ctx context.Context, key string) (Secret, error) {
 }
 
 func (s *Service) saveSecret(ctx context.Context, key string, secret Secret) error {
-	logger := s.logger.With(slog.String("key", key))
+	logger := s.logger.With(keyAttr(key))
 
 	if err := s.store.Save(ctx, key, secret); err != nil {
-		logger.LogAttrs(ctx, slog.LevelError, "Failed to save secret", slog.String("error", err.Error()))
+		level := slog.LevelError
+		if errors.Is(err, ErrStoreFull) {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(ctx, level, "Failed to save secret", slog.String("error", err.Error()))
 
 		return fmt.Errorf("save secret: %w", err)
 	}
@@ -182,7 +306,7 @@ func (s *Service) saveSecret(ctx context.Context, key string, secret Secret) err
 }
 
 func (s *Service) removeSecret(ctx context.Context, key string) error {
-	logger := s.logger.With(slog.String("key", key))
+	logger := s.logger.With(keyAttr(key))
 
 	if err := s.store.Remove(ctx, key); err != nil {
 		logger.LogAttrs(ctx, slog.LevelError, "Failed to remove secret", slog.String("error", err.Error()))
@@ -194,7 +318,26 @@ func (s *Service) removeSecret(ctx context.Context, key string) error {
 	return nil
 }
 
-func (s *Service) encryptMessage(ctx context.Context, passpharse, message string) ([]byte, error) {
+func (s *Service) decrementAttempts(ctx context.Context, store AttemptsDecrementer, key string) error {
+	logger := s.logger.With(keyAttr(key))
+
+	attempts, err := store.DecrementAttempts(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		logger.LogAttrs(ctx, slog.LevelInfo, "Secret removed before attempts decrement")
+
+		return nil
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed to decrement secret attempts", slog.String("error", err.Error()))
+
+		return fmt.Errorf("decrement attempts: %w", err)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "Secret attempts decremented", slog.Int("attempts", attempts))
+
+	return nil
+}
+
+func (s *Service) encryptMessage(ctx context.Context, passpharse string, message []byte) ([]byte, error) {
 	bytes, err := s.encryptor.Encrypt(ctx, passpharse, message)
 	if err != nil {
 		s.logger.LogAttrs(ctx, slog.LevelError, "Failed to encrypt message", slog.String("error", err.Error()))
@@ -206,7 +349,7 @@ func (s *Service) encryptMessage(ctx context.Context, passpharse, message string
 	return bytes, nil
 }
 
-func (s *Service) decryptData(ctx context.Context, passphrase string, data []byte) (string, error) {
+func (s *Service) decryptData(ctx context.Context, passphrase string, data []byte) ([]byte, error) {
 	message, err := s.encryptor.Decrypt(ctx, passphrase, data)
 	if err != nil {
 		level := slog.LevelInfo
@@ -236,3 +379,15 @@ func (s *Service) generateStoreKey(ctx context.Context) (string, error) {
 
 	return hex.EncodeToString(key), nil
 }
+
+func KeyFingerprint(key string) string {
+	const length = 6
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:length])
+}
+
+func keyAttr(key string) slog.Attr {
+	return slog.String("keyFingerprint", KeyFingerprint(key))
+}
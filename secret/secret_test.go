@@ -2,12 +2,18 @@ package secret
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/pugkong/sharesecrets/loggertest"
+	"github.com/pugkong/sharesecrets/tracing"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestService(t *testing.T) {
@@ -21,12 +27,12 @@ func TestService(t *testing.T) {
 		}
 
 		encryptor := NewSecretboxEncryptor(logger)
-		store := NewInMemoryStore(logger)
-		service := NewService(logger, encryptor, store, time.Minute, time.Now)
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+		service := NewService(logger, encryptor, store, CleanupSchedule{Interval: time.Minute}, time.Now)
 
 		key, err := service.Store(ctx, StoreRequest{
 			Passphrase: input.Passpharse,
-			Message:    input.Message,
+			Message:    []byte(input.Message),
 			Attempts:   1,
 			ExpireAt:   time.Now().Add(time.Minute),
 		})
@@ -38,14 +44,14 @@ func TestService(t *testing.T) {
 
 		message, err := encryptor.Decrypt(ctx, input.Passpharse, secret.data)
 		require.NoError(t, err)
-		require.Equal(t, input.Message, message)
+		require.Equal(t, []byte(input.Message), message)
 
 		message, err = service.Retrieve(ctx, RetrieveRequest{
 			Key:        key,
 			Passphrase: input.Passpharse,
 		})
 		require.NoError(t, err)
-		require.Equal(t, input.Message, message)
+		require.Equal(t, []byte(input.Message), message)
 
 		_, err = store.Load(ctx, key)
 		require.Error(t, err)
@@ -53,7 +59,13 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("it returns error when secret not found", func(t *testing.T) {
-		service := NewService(logger, NewSecretboxEncryptor(logger), NewInMemoryStore(logger), time.Minute, time.Now)
+		service := NewService(
+			logger,
+			NewSecretboxEncryptor(logger),
+			NewInMemoryStore(logger, InMemoryLimits{}),
+			CleanupSchedule{Interval: time.Minute},
+			time.Now,
+		)
 
 		_, err := service.Retrieve(ctx, RetrieveRequest{
 			Key:        "not-found",
@@ -66,11 +78,17 @@ func TestService(t *testing.T) {
 	t.Run("it returns error when invalid passphrase provided", func(t *testing.T) {
 		const passphrase = "passphrase"
 
-		service := NewService(logger, NewSecretboxEncryptor(logger), NewInMemoryStore(logger), time.Minute, time.Now)
+		service := NewService(
+			logger,
+			NewSecretboxEncryptor(logger),
+			NewInMemoryStore(logger, InMemoryLimits{}),
+			CleanupSchedule{Interval: time.Minute},
+			time.Now,
+		)
 
 		key, err := service.Store(ctx, StoreRequest{
 			Passphrase: passphrase,
-			Message:    "Message",
+			Message:    []byte("Message"),
 			Attempts:   1,
 			ExpireAt:   time.Now().Add(time.Minute),
 		})
@@ -87,11 +105,17 @@ func TestService(t *testing.T) {
 	t.Run("it respects attempts limit", func(t *testing.T) {
 		const passphrase = "passphrase"
 
-		service := NewService(logger, NewSecretboxEncryptor(logger), NewInMemoryStore(logger), time.Minute, time.Now)
+		service := NewService(
+			logger,
+			NewSecretboxEncryptor(logger),
+			NewInMemoryStore(logger, InMemoryLimits{}),
+			CleanupSchedule{Interval: time.Minute},
+			time.Now,
+		)
 
 		key, err := service.Store(ctx, StoreRequest{
 			Passphrase: passphrase,
-			Message:    "Message",
+			Message:    []byte("Message"),
 			Attempts:   2,
 			ExpireAt:   time.Now().Add(time.Minute),
 		})
@@ -117,14 +141,14 @@ func TestService(t *testing.T) {
 		service := NewService(
 			logger,
 			NewSecretboxEncryptor(logger),
-			NewInMemoryStore(logger),
-			time.Minute,
+			NewInMemoryStore(logger, InMemoryLimits{}),
+			CleanupSchedule{Interval: time.Minute},
 			func() time.Time { return time.Now().Add(1 * time.Minute) },
 		)
 
 		key, err := service.Store(ctx, StoreRequest{
 			Passphrase: passphrase,
-			Message:    "Message",
+			Message:    []byte("Message"),
 			Attempts:   1,
 			ExpireAt:   time.Now(),
 		})
@@ -137,4 +161,166 @@ func TestService(t *testing.T) {
 		require.Error(t, err)
 		require.ErrorIs(t, err, ErrExpired)
 	})
+
+	t.Run("it logs key fingerprints instead of keys", func(t *testing.T) {
+		const passphrase = "passphrase"
+
+		logger, output := loggertest.New()
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+		service := NewService(logger, NewSecretboxEncryptor(logger), store, CleanupSchedule{Interval: time.Minute}, time.Now)
+
+		key, err := service.Store(ctx, StoreRequest{
+			Passphrase: passphrase,
+			Message:    []byte("Message"),
+			Attempts:   2,
+			ExpireAt:   time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = service.Retrieve(ctx, RetrieveRequest{Key: key, Passphrase: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidPassphrase)
+		_, err = service.Retrieve(ctx, RetrieveRequest{Key: key, Passphrase: passphrase})
+		require.NoError(t, err)
+
+		out := output(t)
+		loggertest.RequireNotLogged(t, out, key)
+		require.Contains(t, out, map[string]any{"level": "INFO", "msg": "Secret saved", "keyFingerprint": KeyFingerprint(key)})
+	})
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	fingerprint := KeyFingerprint("0123456789abcdef0123456789abcdef")
+
+	require.Len(t, fingerprint, 12)
+	require.Equal(t, fingerprint, KeyFingerprint("0123456789abcdef0123456789abcdef"))
+	require.NotEqual(t, fingerprint, KeyFingerprint("fedcba9876543210fedcba9876543210"))
+}
+
+func TestService_CheckCleanup(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := time.Now()
+	service := NewService(
+		logger,
+		NewSecretboxEncryptor(logger),
+		NewInMemoryStore(logger, InMemoryLimits{}),
+		CleanupSchedule{Interval: time.Minute},
+		func() time.Time { return now },
+	)
+
+	err := service.CheckCleanup(ctx)
+	require.ErrorIs(t, err, errCleanupNotStarted)
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = service.CleanupLoop(loopCtx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	err = service.CheckCleanup(ctx)
+	require.NoError(t, err)
+
+	now = now.Add(3*time.Minute + time.Second)
+	err = service.CheckCleanup(ctx)
+	require.ErrorIs(t, err, errCleanupStale)
+}
+
+func TestService_CleanupLoop(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	store := NewInMemoryStore(logger, InMemoryLimits{})
+	service := NewService(logger, NewSecretboxEncryptor(logger), store, CleanupSchedule{Interval: time.Hour}, time.Now)
+
+	saveExpired := func(key string) {
+		err := store.Save(ctx, key, Secret{exp: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+	}
+	removed := func(key string) func() bool {
+		return func() bool {
+			_, err := store.Load(ctx, key)
+
+			return errors.Is(err, ErrNotFound)
+		}
+	}
+
+	saveExpired("startup")
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- service.CleanupLoop(loopCtx) }()
+
+	require.Eventually(t, removed("startup"), time.Second, time.Millisecond)
+
+	saveExpired("trigger")
+	require.True(t, service.TriggerCleanup())
+	require.Eventually(t, removed("trigger"), time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestService_NextCleanup(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	service := NewService(logger, nil, nil, CleanupSchedule{Interval: time.Minute}, time.Now)
+	require.Equal(t, time.Minute, service.nextCleanup())
+
+	service = NewService(logger, nil, nil, CleanupSchedule{Interval: time.Minute, Jitter: 10 * time.Second}, time.Now)
+	for range 100 {
+		next := service.nextCleanup()
+		require.GreaterOrEqual(t, next, time.Minute)
+		require.Less(t, next, time.Minute+10*time.Second)
+	}
+}
+
+func TestServiceTracing(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracing.SetGlobal(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	service := NewService(
+		logger,
+		NewSecretboxEncryptor(logger),
+		NewInMemoryStore(logger, InMemoryLimits{}),
+		CleanupSchedule{Interval: time.Minute},
+		time.Now,
+	)
+
+	key, err := service.Store(ctx, StoreRequest{Message: []byte("Message"), Attempts: 1, ExpireAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	_, err = service.Retrieve(ctx, RetrieveRequest{Key: key})
+	require.NoError(t, err)
+
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	require.Equal(
+		t,
+		[]string{
+			"SecretboxEncryptor.Encrypt",
+			"InMemoryStore.Save",
+			"Service.Store",
+			"InMemoryStore.Load",
+			"SecretboxEncryptor.Decrypt",
+			"InMemoryStore.Remove",
+			"Service.Retrieve",
+		},
+		names,
+	)
+
+	spans := exporter.GetSpans()
+	require.Equal(t, spans[2].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	require.Equal(t, spans[6].SpanContext.SpanID(), spans[3].Parent.SpanID())
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/pugkong/sharesecrets/tracing"
 	"golang.org/x/crypto/nacl/secretbox"
 )
 
@@ -19,24 +20,28 @@ func NewSecretboxEncryptor(logger *slog.Logger) *SecretboxEncryptor {
 	return &SecretboxEncryptor{logger: logger}
 }
 
-func (e *SecretboxEncryptor) Encrypt(ctx context.Context, passphrase, message string) ([]byte, error) {
+func (e *SecretboxEncryptor) Encrypt(ctx context.Context, passphrase string, message []byte) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SecretboxEncryptor.Encrypt")
+	defer span.End()
+
 	key, random, err := e.makeKey(ctx, passphrase)
+	defer clear(key[:])
 	if err != nil {
-		return nil, err
+		return nil, tracing.Fail(span, err)
 	}
 
 	var nonce [24]byte
 	if _, err := rand.Read(nonce[:]); err != nil {
 		e.logger.LogAttrs(ctx, slog.LevelError, "Failed to generate nonce", slog.String("error", err.Error()))
 
-		return nil, fmt.Errorf("generate nonce: %w", err)
+		return nil, tracing.Fail(span, fmt.Errorf("generate nonce: %w", err))
 	}
 
 	var out []byte
 	out = append(out, random...)
 	out = append(out, nonce[:]...)
 
-	data := secretbox.Seal(out, []byte(message), &nonce, &key)
+	data := secretbox.Seal(out, message, &nonce, &key)
 	e.logger.DebugContext(ctx, "Message encrypted")
 
 	return data, nil
@@ -61,18 +66,22 @@ func (e *SecretboxEncryptor) makeKey(ctx context.Context, passphrase string) ([3
 	return key, random, nil
 }
 
-func (e *SecretboxEncryptor) Decrypt(ctx context.Context, passphrase string, data []byte) (string, error) {
+func (e *SecretboxEncryptor) Decrypt(ctx context.Context, passphrase string, data []byte) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SecretboxEncryptor.Decrypt")
+	defer span.End()
+
 	key, nonce, box := e.splitData(passphrase, data)
+	defer clear(key[:])
 
 	message, ok := secretbox.Open(nil, box, &nonce, &key)
 	if !ok {
 		e.logger.DebugContext(ctx, "Invalid passphrase")
 
-		return "", ErrInvalidPassphrase
+		return nil, ErrInvalidPassphrase
 	}
 	e.logger.DebugContext(ctx, "Message decrypted")
 
-	return string(message), nil
+	return message, nil
 }
 
 func (e *SecretboxEncryptor) splitData(passphrase string, data []byte) ([32]byte, [24]byte, []byte) {
@@ -14,10 +14,8 @@ func TestSecretboxEncryptor(t *testing.T) {
 	encryptor := NewSecretboxEncryptor(logger)
 	ctx := context.Background()
 
-	const (
-		passpharse = "passpharse"
-		data       = "data"
-	)
+	const passpharse = "passpharse"
+	data := []byte("data")
 
 	encrypted, err := encryptor.Encrypt(ctx, passpharse, data)
 	require.NoError(t, err)
@@ -0,0 +1,73 @@
+package secret_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/pugkong/sharesecrets/secrettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_WithFakes(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newService := func(encryptor secret.Encryptor, clock *secrettest.Clock) *secret.Service {
+		store := secret.NewInMemoryStore(logger, secret.InMemoryLimits{})
+
+		return secret.NewService(logger, encryptor, store, secret.CleanupSchedule{Interval: time.Minute}, clock.Now)
+	}
+
+	t.Run("it expires secrets by service clock", func(t *testing.T) {
+		clock := secrettest.NewClock(time.Now())
+		service := newService(&secrettest.Encryptor{}, clock)
+
+		request := secret.StoreRequest{
+			Passphrase: "passphrase",
+			Message:    []byte("Message"),
+			Attempts:   1,
+			ExpireAt:   clock.Now().Add(time.Minute),
+		}
+		key, err := service.Store(ctx, request)
+		require.NoError(t, err)
+
+		clock.Advance(2 * time.Minute)
+
+		_, err = service.Retrieve(ctx, secret.RetrieveRequest{Key: key, Passphrase: "passphrase"})
+		require.ErrorIs(t, err, secret.ErrExpired)
+	})
+
+	t.Run("it reports encryptor failures", func(t *testing.T) {
+		encryptErr := errors.New("encrypt failed")
+		service := newService(&secrettest.Encryptor{EncryptErr: encryptErr}, secrettest.NewClock(time.Now()))
+
+		_, err := service.Store(ctx, secret.StoreRequest{Message: []byte("Message"), Attempts: 1, ExpireAt: time.Now().Add(time.Minute)})
+		require.ErrorIs(t, err, encryptErr)
+	})
+
+	t.Run("it rejects invalid passphrase", func(t *testing.T) {
+		clock := secrettest.NewClock(time.Now())
+		service := newService(&secrettest.Encryptor{}, clock)
+
+		request := secret.StoreRequest{
+			Passphrase: "passphrase",
+			Message:    []byte("Message"),
+			Attempts:   2,
+			ExpireAt:   clock.Now().Add(time.Minute),
+		}
+		key, err := service.Store(ctx, request)
+		require.NoError(t, err)
+
+		_, err = service.Retrieve(ctx, secret.RetrieveRequest{Key: key, Passphrase: "invalid"})
+		require.ErrorIs(t, err, secret.ErrInvalidPassphrase)
+
+		message, err := service.Retrieve(ctx, secret.RetrieveRequest{Key: key, Passphrase: "passphrase"})
+		require.NoError(t, err)
+		require.Equal(t, []byte("Message"), message)
+	})
+}
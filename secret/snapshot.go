@@ -0,0 +1,414 @@
+package secret
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pugkong/sharesecrets/tracing"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	snapshotKeySize   = 32
+	snapshotNonceSize = 24
+	snapshotFileMode  = 0o600
+)
+
+var (
+	errSnapshotKey       = errors.New("snapshot key must be 32 base64 encoded bytes")
+	errSnapshotDecrypt   = errors.New("decrypt snapshot: invalid key or corrupted file")
+	errSnapshotTruncated = errors.New("snapshot is truncated")
+)
+
+type SnapshotKey [snapshotKeySize]byte
+
+func ParseSnapshotKey(encoded string) (SnapshotKey, error) {
+	var key SnapshotKey
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != snapshotKeySize {
+		return key, errSnapshotKey
+	}
+	copy(key[:], decoded)
+	clear(decoded)
+
+	return key, nil
+}
+
+type snapshotEntry struct {
+	Key      string `json:"key"`
+	Data     []byte `json:"data"`
+	Attempts int    `json:"attempts"`
+	Exp      int64  `json:"exp"`
+}
+
+type journalEntry struct {
+	Key      string `json:"key"`
+	Attempts int    `json:"attempts"`
+}
+
+type Snapshotter struct {
+	logger   *slog.Logger
+	store    *InMemoryStore
+	path     string
+	key      SnapshotKey
+	interval time.Duration
+	now      func() time.Time
+
+	journalLock sync.Mutex
+	journal     *os.File
+	journalSize int64
+}
+
+func NewSnapshotter(
+	logger *slog.Logger,
+	store *InMemoryStore,
+	path string,
+	key SnapshotKey,
+	interval time.Duration,
+	now func() time.Time,
+) *Snapshotter {
+	snapshotter := &Snapshotter{
+		logger:   logger,
+		store:    store,
+		path:     path,
+		key:      key,
+		interval: interval,
+		now:      now,
+	}
+	store.journal = snapshotter.record
+	store.purged = snapshotter.Save
+
+	return snapshotter
+}
+
+func (s *Snapshotter) Restore(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, tracerName, "Snapshotter.Restore")
+	defer span.End()
+
+	var entries []snapshotEntry
+	sealed, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "No snapshot to restore", slog.String("path", s.path))
+	case err != nil:
+		return tracing.Fail(span, fmt.Errorf("read snapshot: %w", err))
+	default:
+		if entries, err = s.open(sealed); err != nil {
+			return tracing.Fail(span, err)
+		}
+	}
+	defer func() {
+		for _, entry := range entries {
+			clear(entry.Data)
+		}
+	}()
+
+	changes, err := s.readJournal(ctx)
+	if err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	var restored, dropped int
+	now := s.now()
+	for _, entry := range entries {
+		secret := Secret{data: entry.Data, attempts: entry.Attempts, exp: time.Unix(0, entry.Exp)}
+		if attempts, ok := changes[journalKey(entry.Key)]; ok {
+			secret.attempts = min(secret.attempts, attempts)
+		}
+		if now.After(secret.exp) || secret.attempts <= 0 {
+			dropped++
+
+			continue
+		}
+
+		if err := s.store.Save(ctx, entry.Key, secret); err != nil {
+			dropped += len(entries) - restored - dropped
+			s.logger.LogAttrs(ctx, slog.LevelWarn, "Snapshot restore stopped early", slog.String("error", err.Error()))
+
+			break
+		}
+		restored++
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "Snapshot restored",
+		slog.String("path", s.path),
+		slog.Int("restored", restored),
+		slog.Int("dropped", dropped),
+	)
+
+	if err := s.Save(ctx); err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	return nil
+}
+
+func (s *Snapshotter) Save(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, tracerName, "Snapshotter.Save")
+	defer span.End()
+
+	start := time.Now()
+
+	s.journalLock.Lock()
+	err := s.openJournal()
+	journaled := s.journalSize
+	s.journalLock.Unlock()
+	if err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	entries := s.store.snapshot()
+	defer func() {
+		for _, entry := range entries {
+			clear(entry.Data)
+		}
+	}()
+
+	sealed, err := s.seal(entries)
+	if err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	if err := writeFileAtomic(s.path, sealed); err != nil {
+		return tracing.Fail(span, err)
+	}
+	if err := s.compactJournal(journaled); err != nil {
+		return tracing.Fail(span, err)
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "Snapshot saved",
+		slog.String("path", s.path),
+		slog.Int("secrets", len(entries)),
+		slog.String("duration", time.Since(start).String()),
+	)
+
+	return nil
+}
+
+func (s *Snapshotter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.InfoContext(ctx, "Snapshot loop started")
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Save(ctx); err != nil {
+				s.logger.LogAttrs(ctx, slog.LevelError, "Failed to save snapshot", slog.String("error", err.Error()))
+			}
+		case <-ctx.Done():
+			s.logger.InfoContext(ctx, "Snapshot loop stopped")
+
+			return fmt.Errorf("snapshots: %w", ctx.Err())
+		}
+	}
+}
+
+func (s *Snapshotter) Close() error {
+	s.journalLock.Lock()
+	defer s.journalLock.Unlock()
+
+	if s.journal == nil {
+		return nil
+	}
+
+	err := s.journal.Close()
+	s.journal = nil
+	if err != nil {
+		return fmt.Errorf("close snapshot journal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Snapshotter) record(key string, attempts int) error {
+	line, err := json.Marshal(journalEntry{Key: journalKey(key), Attempts: max(attempts, 0)})
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.journalLock.Lock()
+	defer s.journalLock.Unlock()
+
+	if err := s.openJournal(); err != nil {
+		return err
+	}
+
+	n, err := s.journal.Write(line)
+	s.journalSize += int64(n)
+	if err == nil {
+		err = s.journal.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot journal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Snapshotter) openJournal() error {
+	if s.journal != nil {
+		return nil
+	}
+
+	file, err := os.OpenFile(s.journalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, snapshotFileMode)
+	if err != nil {
+		return fmt.Errorf("open snapshot journal: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("stat snapshot journal: %w", err)
+	}
+
+	s.journal = file
+	s.journalSize = info.Size()
+
+	return nil
+}
+
+func (s *Snapshotter) readJournal(ctx context.Context) (map[string]int, error) {
+	content, err := os.ReadFile(s.journalPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot journal: %w", err)
+	}
+
+	changes := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelWarn, "Skipped corrupted journal entry", slog.String("error", err.Error()))
+
+			continue
+		}
+
+		if attempts, ok := changes[entry.Key]; !ok || entry.Attempts < attempts {
+			changes[entry.Key] = entry.Attempts
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read snapshot journal: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (s *Snapshotter) compactJournal(snapshotted int64) error {
+	s.journalLock.Lock()
+	defer s.journalLock.Unlock()
+
+	if s.journalSize == 0 {
+		return nil
+	}
+
+	content, err := os.ReadFile(s.journalPath())
+	if err != nil {
+		return fmt.Errorf("read snapshot journal: %w", err)
+	}
+
+	if err := writeFileAtomic(s.journalPath(), content[min(snapshotted, int64(len(content))):]); err != nil {
+		return err
+	}
+
+	_ = s.journal.Close()
+	s.journal = nil
+
+	return s.openJournal()
+}
+
+func (s *Snapshotter) journalPath() string {
+	return s.path + ".journal"
+}
+
+func journalKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Snapshotter) seal(entries []snapshotEntry) ([]byte, error) {
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+	defer clear(plain)
+
+	var nonce [snapshotNonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	key := [snapshotKeySize]byte(s.key)
+
+	return secretbox.Seal(nonce[:], plain, &nonce, &key), nil
+}
+
+func (s *Snapshotter) open(sealed []byte) ([]snapshotEntry, error) {
+	if len(sealed) < snapshotNonceSize {
+		return nil, errSnapshotTruncated
+	}
+
+	nonce := [snapshotNonceSize]byte(sealed[:snapshotNonceSize])
+	key := [snapshotKeySize]byte(s.key)
+
+	plain, ok := secretbox.Open(nil, sealed[snapshotNonceSize:], &nonce, &key)
+	if !ok {
+		return nil, errSnapshotDecrypt
+	}
+	defer clear(plain)
+
+	var entries []snapshotEntry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	return entries, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(snapshotFileMode)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("replace snapshot file: %w", err)
+	}
+
+	return nil
+}
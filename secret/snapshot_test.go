@@ -0,0 +1,206 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotKey(t *testing.T) {
+	tests := map[string]struct {
+		encoded string
+		err     error
+	}{
+		"valid key":      {encoded: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		"short key":      {encoded: base64.StdEncoding.EncodeToString(make([]byte, 16)), err: errSnapshotKey},
+		"invalid base64": {encoded: strings.Repeat("!", 44), err: errSnapshotKey},
+		"empty key":      {encoded: "", err: errSnapshotKey},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSnapshotKey(test.encoded)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestSnapshotter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	key := SnapshotKey{1, 2, 3}
+
+	newSnapshotter := func(path string, key SnapshotKey, now time.Time) (*Snapshotter, *InMemoryStore) {
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+
+		return NewSnapshotter(logger, store, path, key, time.Minute, func() time.Time { return now }), store
+	}
+
+	t.Run("it saves and restores secrets dropping expired ones", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		now := time.Now()
+
+		snapshotter, store := newSnapshotter(path, key, now)
+		require.NoError(t, store.Save(ctx, "active", Secret{data: []byte("data"), attempts: 2, exp: now.Add(time.Hour)}))
+		require.NoError(t, store.Save(ctx, "expiring", Secret{data: []byte("data"), attempts: 3, exp: now.Add(time.Minute)}))
+		require.NoError(t, snapshotter.Save(ctx))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(snapshotFileMode), info.Mode().Perm())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(content), "active")
+
+		restorer, restored := newSnapshotter(path, key, now.Add(30*time.Minute))
+		require.NoError(t, restorer.Restore(ctx))
+
+		secret, err := restored.Load(ctx, "active")
+		require.NoError(t, err)
+		require.Equal(t, []byte("data"), secret.data)
+		require.Equal(t, 2, secret.attempts)
+		require.True(t, now.Add(time.Hour).Equal(secret.exp))
+
+		_, err = restored.Load(ctx, "expiring")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("it replays removals and attempts made after the snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		now := time.Now()
+
+		snapshotter, store := newSnapshotter(path, key, now)
+		require.NoError(t, store.Save(ctx, "burned", Secret{data: []byte("data"), attempts: 3, exp: now.Add(time.Hour)}))
+		require.NoError(t, store.Save(ctx, "guessed", Secret{data: []byte("data"), attempts: 3, exp: now.Add(time.Hour)}))
+		require.NoError(t, snapshotter.Save(ctx))
+
+		require.NoError(t, store.Remove(ctx, "burned"))
+		require.NoError(t, store.Save(ctx, "guessed", Secret{data: []byte("data"), attempts: 1, exp: now.Add(time.Hour)}))
+		require.NoError(t, snapshotter.Close())
+
+		journal, err := os.ReadFile(path + ".journal")
+		require.NoError(t, err)
+		require.NotContains(t, string(journal), "burned")
+		require.NotContains(t, string(journal), "guessed")
+
+		restorer, restored := newSnapshotter(path, key, now)
+		require.NoError(t, restorer.Restore(ctx))
+
+		_, err = restored.Load(ctx, "burned")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		secret, err := restored.Load(ctx, "guessed")
+		require.NoError(t, err)
+		require.Equal(t, 1, secret.attempts)
+	})
+
+	t.Run("it rewrites snapshot after restore", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		now := time.Now()
+
+		snapshotter, store := newSnapshotter(path, key, now)
+		require.NoError(t, store.Save(ctx, "burned", Secret{data: []byte("data"), attempts: 1, exp: now.Add(time.Hour)}))
+		require.NoError(t, store.Save(ctx, "expiring", Secret{data: []byte("data"), attempts: 1, exp: now.Add(time.Minute)}))
+		require.NoError(t, snapshotter.Save(ctx))
+		require.NoError(t, store.Remove(ctx, "burned"))
+		require.NoError(t, snapshotter.Close())
+
+		restorer, _ := newSnapshotter(path, key, now.Add(30*time.Minute))
+		require.NoError(t, restorer.Restore(ctx))
+		require.NoError(t, restorer.Close())
+
+		info, err := os.Stat(path + ".journal")
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+
+		entries, err := restorer.open(readFile(t, path))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("it forgets purged secrets", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		now := time.Now()
+
+		snapshotter, store := newSnapshotter(path, key, now)
+		require.NoError(t, store.Save(ctx, "snapshotted", Secret{data: []byte("data"), attempts: 1, exp: now.Add(time.Hour)}))
+		require.NoError(t, snapshotter.Save(ctx))
+		require.NoError(t, store.Save(ctx, "journaled", Secret{data: []byte("data"), attempts: 1, exp: now.Add(time.Hour)}))
+		require.NoError(t, store.Save(ctx, "journaled", Secret{data: []byte("data"), attempts: 2, exp: now.Add(time.Hour)}))
+
+		purged, err := store.Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, purged)
+		require.NoError(t, snapshotter.Close())
+
+		info, err := os.Stat(path + ".journal")
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+
+		restorer, restored := newSnapshotter(path, key, now)
+		require.NoError(t, restorer.Restore(ctx))
+		require.Zero(t, restored.Usage().Secrets)
+	})
+
+	t.Run("it ignores missing snapshot", func(t *testing.T) {
+		snapshotter, store := newSnapshotter(filepath.Join(t.TempDir(), "snapshot"), key, time.Now())
+
+		require.NoError(t, snapshotter.Restore(ctx))
+		require.Equal(t, 0, store.Usage().Secrets)
+	})
+
+	t.Run("it rejects snapshot encrypted with another key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+
+		snapshotter, _ := newSnapshotter(path, key, time.Now())
+		require.NoError(t, snapshotter.Save(ctx))
+
+		restorer, _ := newSnapshotter(path, SnapshotKey{3, 2, 1}, time.Now())
+		require.ErrorIs(t, restorer.Restore(ctx), errSnapshotDecrypt)
+	})
+
+	t.Run("it rejects truncated snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		require.NoError(t, os.WriteFile(path, []byte("short"), 0o600))
+
+		snapshotter, _ := newSnapshotter(path, key, time.Now())
+		require.ErrorIs(t, snapshotter.Restore(ctx), errSnapshotTruncated)
+	})
+
+	t.Run("it saves snapshots periodically", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		store := NewInMemoryStore(logger, InMemoryLimits{})
+		snapshotter := NewSnapshotter(logger, store, path, key, time.Millisecond, time.Now)
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- snapshotter.Run(loopCtx) }()
+
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(path)
+
+			return err == nil
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return content
+}
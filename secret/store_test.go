@@ -0,0 +1,61 @@
+package secret_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/pugkong/sharesecrets/secrettest"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("InMemoryStore", func(t *testing.T) {
+		secrettest.RunStoreSuite(t, func(*testing.T) secret.Store {
+			return secret.NewInMemoryStore(logger, secret.InMemoryLimits{})
+		})
+	})
+
+	t.Run("BoltStore", func(t *testing.T) {
+		secrettest.RunStoreSuite(t, func(t *testing.T) secret.Store {
+			store, err := secret.OpenBoltStore(filepath.Join(t.TempDir(), "secrets.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = store.Close() })
+
+			return store
+		})
+	})
+
+	t.Run("RedisStore", func(t *testing.T) {
+		secrettest.RunStoreSuite(t, func(t *testing.T) secret.Store {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { _ = client.Close() })
+
+			return secret.NewRedisStore(client)
+		})
+	})
+}
+
+func TestPgStoreSuite(t *testing.T) {
+	ctx := context.Background()
+	pool := secret.StartPostgres(t)
+
+	store := secret.NewPgStore(pool, secret.PgCleanupConfig{BatchSize: 100, Cutoff: time.Minute})
+	require.NoError(t, store.Init(ctx))
+
+	secrettest.RunStoreSuite(t, func(t *testing.T) secret.Store {
+		_, err := store.Purge(ctx)
+		require.NoError(t, err)
+
+		return store
+	})
+}
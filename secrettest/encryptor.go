@@ -0,0 +1,38 @@
+package secrettest
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pugkong/sharesecrets/secret"
+)
+
+var _ secret.Encryptor = &Encryptor{}
+
+var separator = []byte{0}
+
+type Encryptor struct {
+	EncryptErr error
+	DecryptErr error
+}
+
+func (e *Encryptor) Encrypt(_ context.Context, passphrase string, message []byte) ([]byte, error) {
+	if e.EncryptErr != nil {
+		return nil, e.EncryptErr
+	}
+
+	return bytes.Join([][]byte{[]byte(passphrase), message}, separator), nil
+}
+
+func (e *Encryptor) Decrypt(_ context.Context, passphrase string, data []byte) ([]byte, error) {
+	if e.DecryptErr != nil {
+		return nil, e.DecryptErr
+	}
+
+	stored, message, ok := bytes.Cut(data, separator)
+	if !ok || string(stored) != passphrase {
+		return nil, secret.ErrInvalidPassphrase
+	}
+
+	return bytes.Clone(message), nil
+}
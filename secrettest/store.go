@@ -0,0 +1,154 @@
+package secrettest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pugkong/sharesecrets/secret"
+	"github.com/stretchr/testify/require"
+)
+
+const timePrecision = time.Millisecond
+
+func RunStoreSuite(t *testing.T, newStore func(t *testing.T) secret.Store) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	requireSecret := func(t *testing.T, expected, actual secret.Secret) {
+		t.Helper()
+
+		require.Equal(t, expected.Data(), actual.Data())
+		require.Equal(t, expected.Attempts(), actual.Attempts())
+		require.WithinDuration(t, expected.ExpireAt(), actual.ExpireAt(), timePrecision)
+	}
+
+	t.Run("it saves and loads secrets", func(t *testing.T) {
+		store := newStore(t)
+
+		saved := secret.NewSecret([]byte("store test"), 3, time.Now().Add(time.Minute))
+		require.NoError(t, store.Save(ctx, "key", saved))
+
+		loaded, err := store.Load(ctx, "key")
+		require.NoError(t, err)
+		requireSecret(t, saved, loaded)
+	})
+
+	t.Run("it overwrites secrets", func(t *testing.T) {
+		store := newStore(t)
+
+		require.NoError(t, store.Save(ctx, "key", secret.NewSecret([]byte("first"), 3, time.Now().Add(time.Minute))))
+
+		overwritten := secret.NewSecret([]byte("second"), 2, time.Now().Add(time.Hour))
+		require.NoError(t, store.Save(ctx, "key", overwritten))
+
+		loaded, err := store.Load(ctx, "key")
+		require.NoError(t, err)
+		requireSecret(t, overwritten, loaded)
+	})
+
+	t.Run("it removes secrets", func(t *testing.T) {
+		store := newStore(t)
+
+		require.NoError(t, store.Save(ctx, "key", secret.NewSecret([]byte("data"), 1, time.Now().Add(time.Minute))))
+		require.NoError(t, store.Remove(ctx, "key"))
+
+		_, err := store.Load(ctx, "key")
+		require.ErrorIs(t, err, secret.ErrNotFound)
+	})
+
+	t.Run("it ignores removal of missing secrets", func(t *testing.T) {
+		store := newStore(t)
+
+		require.NoError(t, store.Remove(ctx, "missing"))
+	})
+
+	t.Run("it reports missing secrets", func(t *testing.T) {
+		store := newStore(t)
+
+		_, err := store.Load(ctx, "missing")
+		require.ErrorIs(t, err, secret.ErrNotFound)
+	})
+
+	t.Run("it removes expired secrets on cleanup", func(t *testing.T) {
+		store := newStore(t)
+
+		require.NoError(t, store.Save(ctx, "expired", secret.NewSecret([]byte("data"), 1, time.Now().Add(-time.Minute))))
+		require.NoError(t, store.Save(ctx, "active", secret.NewSecret([]byte("data"), 1, time.Now().Add(time.Minute))))
+
+		stats, err := store.Stats(ctx)
+		require.NoError(t, err)
+
+		removed, err := store.Cleanup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, stats.Expired, removed)
+
+		stats, err = store.Stats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, secret.Stats{Active: 1, Bytes: int64(len("data"))}, stats)
+
+		_, err = store.Load(ctx, "expired")
+		require.ErrorIs(t, err, secret.ErrNotFound)
+
+		_, err = store.Load(ctx, "active")
+		require.NoError(t, err)
+	})
+
+	t.Run("it handles concurrent access", func(t *testing.T) {
+		const (
+			workers    = 8
+			iterations = 25
+		)
+
+		store := newStore(t)
+		shared := secret.NewSecret([]byte("shared"), 1, time.Now().Add(time.Minute))
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*iterations)
+		for worker := range workers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for i := range iterations {
+					key := fmt.Sprintf("key-%d-%d", worker, i)
+					saved := secret.NewSecret([]byte(key), i+1, time.Now().Add(time.Minute))
+
+					if err := store.Save(ctx, key, saved); err != nil {
+						errs <- err
+
+						continue
+					}
+					if err := store.Save(ctx, "shared", shared); err != nil {
+						errs <- err
+					}
+
+					loaded, err := store.Load(ctx, key)
+					if err == nil && string(loaded.Data()) != key {
+						err = fmt.Errorf("key %s loaded %q", key, loaded.Data())
+					}
+					if err == nil {
+						err = store.Remove(ctx, key)
+					}
+					if err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		loaded, err := store.Load(ctx, "shared")
+		require.NoError(t, err)
+		requireSecret(t, shared, loaded)
+	})
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	SetGlobal(provider)
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	return exporter
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("it creates server span", func(t *testing.T) {
+		exporter := newTestProvider(t)
+
+		var spanContext trace.SpanContext
+		handler := NewMiddleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			spanContext = trace.SpanContextFromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		require.Equal(t, "HTTP GET", spans[0].Name)
+		require.Equal(t, trace.SpanKindServer, spans[0].SpanKind)
+		require.Equal(t, spans[0].SpanContext.SpanID(), spanContext.SpanID())
+	})
+
+	t.Run("it honors traceparent header", func(t *testing.T) {
+		exporter := newTestProvider(t)
+
+		handler := NewMiddleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		require.Equal(t, "HTTP POST", spans[0].Name)
+		require.Equal(t, "0102030405060708090a0b0c0d0e0f10", spans[0].SpanContext.TraceID().String())
+		require.Equal(t, "0102030405060708", spans[0].Parent.SpanID().String())
+		require.True(t, spans[0].Parent.IsRemote())
+	})
+}
+
+func TestStartAndFail(t *testing.T) {
+	exporter := newTestProvider(t)
+
+	_, span := Start(context.Background(), "test", "operation")
+	err := Fail(span, io.EOF)
+	span.End()
+
+	require.ErrorIs(t, err, io.EOF)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "operation", spans[0].Name)
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Equal(t, io.EOF.Error(), spans[0].Status.Description)
+	require.Len(t, spans[0].Events, 1)
+	require.Equal(t, "exception", spans[0].Events[0].Name)
+}